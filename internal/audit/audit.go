@@ -0,0 +1,257 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+const (
+	// defaultMaxSize is the log size in bytes at which it is rotated
+	defaultMaxSize = 10 << 20
+	// defaultMaxBackups is the number of rotated logs kept
+	defaultMaxBackups = 5
+)
+
+// Record describes one provider call
+type Record struct {
+	Time time.Time `json:"time"`
+	// Repo is the root directory of the repository and Remote the URL of its first remote
+	Repo   string `json:"repo"`
+	Remote string `json:"remote,omitempty"`
+	// Commit is the hash of the commit made with the message; empty for dry runs and failures
+	Commit   string `json:"commit,omitempty"`
+	Provider string `json:"provider"`
+	// Endpoint is the host the prompt was sent to; empty for local providers
+	Endpoint string `json:"endpoint,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// PromptHash is the SHA-256 of the request bodies sent to the provider, joined by newlines
+	// when the output was truncated and requested again, so identical prompts can be told
+	// apart without storing them. PromptBytes is their size.
+	PromptHash       string `json:"prompt_hash"`
+	PromptBytes      int    `json:"prompt_bytes"`
+	ResponseBytes    int    `json:"response_bytes"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+	// Redactions is the number of secrets redacted from the diff before it was sent
+	Redactions int    `json:"redactions"`
+	Error      string `json:"error,omitempty"`
+	// Prompt is the request bodies as sent, with the redacted diff, recorded only when include_prompt is set
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// DefaultPath returns the default audit log location
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return home + "/.git-auto-commit-audit.jsonl", nil
+}
+
+// Log is an append-only JSON-lines file of records, rotated when it grows too large
+type Log struct {
+	path          string
+	includePrompt bool
+	maxSize       int64
+	maxBackups    int
+}
+
+// New creates a Log from the audit configuration, or returns nil when auditing is disabled
+func New(cfg *config.AuditConfig) (*Log, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	l := &Log{
+		path:          cfg.Path,
+		includePrompt: cfg.IncludePrompt,
+		maxSize:       defaultMaxSize,
+		maxBackups:    defaultMaxBackups,
+	}
+	if l.path == "" {
+		var err error
+		if l.path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxSize > 0 {
+		l.maxSize = cfg.MaxSize
+	}
+	if cfg.MaxBackups > 0 {
+		l.maxBackups = cfg.MaxBackups
+	}
+	return l, nil
+}
+
+// Path returns the path of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// IncludePrompt reports whether records keep the full prompt
+func (l *Log) IncludePrompt() bool {
+	return l != nil && l.includePrompt
+}
+
+// Append writes the records to the log, rotating it first if they would take it over the size limit
+func (l *Log) Append(records ...Record) error {
+	if l == nil || len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit record: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := l.rotate(int64(buf.Len())); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate renames log to log.1, log.1 to log.2 and so on, dropping the oldest backup,
+// when adding n bytes would exceed the size limit
+func (l *Log) rotate(n int64) error {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if info.Size() == 0 || info.Size()+n <= l.maxSize {
+		return nil
+	}
+
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Read returns the records in the log and its rotated backups, oldest first
+func Read(path string) ([]Record, error) {
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	// Higher backup numbers are older
+	var files []string
+	for i := len(backups); i >= 1; i-- {
+		if p := backupPath(path, i); contains(backups, p) {
+			files = append(files, p)
+		}
+	}
+	files = append(files, path)
+
+	var records []Record
+	for _, p := range files {
+		fileRecords, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func readFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	// Records with a full prompt can be much longer than the default line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return records, nil
+}
+
+// Filter selects records; empty fields match every record
+type Filter struct {
+	// Repo matches records whose repository path or remote contains it
+	Repo     string
+	Provider string
+	// Commit matches records whose commit hash starts with it
+	Commit string
+	Since  time.Time
+}
+
+// Match reports whether a record passes the filter
+func (f Filter) Match(r Record) bool {
+	switch {
+	case f.Repo != "" && !strings.Contains(r.Repo, f.Repo) && !strings.Contains(r.Remote, f.Repo):
+		return false
+	case f.Provider != "" && r.Provider != f.Provider:
+		return false
+	case f.Commit != "" && !strings.HasPrefix(r.Commit, f.Commit):
+		return false
+	case !f.Since.IsZero() && r.Time.Before(f.Since):
+		return false
+	}
+	return true
+}
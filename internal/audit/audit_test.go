@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	if l, err := New(nil); l != nil || err != nil {
+		t.Errorf("Expected no log without configuration, got %v, %v", l, err)
+	}
+	if l, err := New(&config.AuditConfig{Path: "/tmp/audit.jsonl"}); l != nil || err != nil {
+		t.Errorf("Expected no log when disabled, got %v, %v", l, err)
+	}
+
+	l, err := New(&config.AuditConfig{Enabled: true, Path: "/tmp/audit.jsonl", IncludePrompt: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if l.Path() != "/tmp/audit.jsonl" || !l.IncludePrompt() || l.maxSize != defaultMaxSize || l.maxBackups != defaultMaxBackups {
+		t.Errorf("Unexpected log: %+v", l)
+	}
+
+	var none *Log
+	if none.IncludePrompt() || none.Append(Record{}) != nil {
+		t.Error("A nil log should record nothing")
+	}
+}
+
+func TestLog_AppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := New(&config.AuditConfig{Enabled: true, Path: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if err := l.Append(Record{Provider: "openai", PromptHash: "a"}, Record{Provider: "claude", PromptHash: "b"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := l.Append(Record{Provider: "azure", PromptHash: "c"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := hashes(records); got != "abc" {
+		t.Errorf("Expected records abc, got %s", got)
+	}
+}
+
+func TestLog_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// Each record is about 100 bytes, so every append after the first rotates
+	l, err := New(&config.AuditConfig{Enabled: true, Path: path, MaxSize: 150, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for _, hash := range []string{"a", "b", "c", "d"} {
+		if err := l.Append(Record{Provider: "openai", PromptHash: hash}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Expected at most 2 backups")
+	}
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := hashes(records); got != "bcd" {
+		t.Errorf("Expected the oldest record to be dropped and bcd kept in order, got %s", got)
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	records, err := Read(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil || len(records) != 0 {
+		t.Errorf("Expected no records, got %v, %v", records, err)
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := Record{
+		Time:     now,
+		Repo:     "/home/dev/billing",
+		Remote:   "git@github.com:acme/billing.git",
+		Commit:   "4f2a9c1e",
+		Provider: "azure",
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"repo path", Filter{Repo: "/home/dev"}, true},
+		{"remote", Filter{Repo: "acme/billing"}, true},
+		{"other repo", Filter{Repo: "acme/web"}, false},
+		{"provider", Filter{Provider: "azure"}, true},
+		{"other provider", Filter{Provider: "openai"}, false},
+		{"commit prefix", Filter{Commit: "4f2a"}, true},
+		{"other commit", Filter{Commit: "9d"}, false},
+		{"since before", Filter{Since: now.Add(-time.Hour)}, true},
+		{"since after", Filter{Since: now.Add(time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(r); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func hashes(records []Record) string {
+	var s string
+	for _, r := range records {
+		s += r.PromptHash
+	}
+	return s
+}
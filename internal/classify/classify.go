@@ -0,0 +1,138 @@
+package classify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/commitmsg"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+	"github.com/algernon-coop/git-auto-commit/internal/whitespace"
+)
+
+// Classification is the commit type implied by the set of staged files
+type Classification struct {
+	Type  string
+	Scope string
+	// Reason explains the classification, such as "only test files changed"
+	Reason string
+	// Accepted are the commit types consistent with the classification
+	Accepted []string
+}
+
+type category struct {
+	patterns       []string
+	classification Classification
+}
+
+var testPatterns = []string{"*_test.go", "testdata/", "*.test.js", "*.test.ts", "*.spec.js", "*.spec.ts", "__tests__/"}
+
+var docsPatterns = []string{"*.md", "*.rst", "*.adoc", "docs/", "doc/", "LICENSE", "NOTICE", "AUTHORS"}
+
+var ciPatterns = []string{".github/workflows/", ".gitlab-ci.yml", ".circleci/", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml", "bitbucket-pipelines.yml", ".buildkite/"}
+
+var depsPatterns = []string{
+	"go.mod", "go.sum", "go.work", "go.work.sum",
+	"package.json", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"requirements*.txt", "Pipfile", "Pipfile.lock", "poetry.lock", "pyproject.toml",
+	"Cargo.toml", "Cargo.lock", "Gemfile", "Gemfile.lock", "composer.json", "composer.lock",
+}
+
+// categories are checked in order; the first whose patterns match every file wins
+var categories = []category{
+	{testPatterns, Classification{Type: "test", Reason: "only test files changed", Accepted: []string{"test"}}},
+	{docsPatterns, Classification{Type: "docs", Reason: "only documentation changed", Accepted: []string{"docs"}}},
+	{ciPatterns, Classification{Type: "ci", Reason: "only CI configuration changed", Accepted: []string{"ci"}}},
+	{depsPatterns, Classification{Type: "chore", Scope: "deps", Reason: "only dependency manifests and lockfiles changed", Accepted: []string{"chore", "build"}}},
+}
+
+// Classify returns the commit type implied by the staged files, or false when the
+// change touches several kinds of files and the type is left to the model
+func Classify(diff *git.Diff) (Classification, bool) {
+	if len(diff.Files) == 0 {
+		return Classification{}, false
+	}
+
+	for _, c := range categories {
+		if allMatch(diff.Files, c.patterns) {
+			return c.classification, true
+		}
+	}
+
+	if formattingOnly(diff.Files) {
+		return Classification{Type: "style", Reason: "only whitespace and formatting changed", Accepted: []string{"style"}}, true
+	}
+
+	return Classification{}, false
+}
+
+func allMatch(files []git.FileDiff, patterns []string) bool {
+	for _, f := range files {
+		if !pathmatch.Match(patterns, f.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+// formattingOnly reports whether every file is modified in place and its deleted and
+// added lines differ only in whitespace, in file types where whitespace has no meaning
+func formattingOnly(files []git.FileDiff) bool {
+	for _, f := range files {
+		if f.Status != git.StatusModified || f.Binary || len(f.Hunks) == 0 || whitespace.Significant(f.Path) {
+			return false
+		}
+		for _, h := range f.Hunks {
+			var deleted, added []string
+			for _, line := range h.Lines {
+				switch {
+				case strings.HasPrefix(line, "-"):
+					deleted = append(deleted, line[1:])
+				case strings.HasPrefix(line, "+"):
+					added = append(added, line[1:])
+				}
+			}
+			if whitespace.Strip(strings.Join(deleted, "")) != whitespace.Strip(strings.Join(added, "")) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Hint describes the classification for inclusion in the prompt
+func (c Classification) Hint() string {
+	return fmt.Sprintf("Suggested commit type: %s (%s)", c.header(), c.Reason)
+}
+
+func (c Classification) header() string {
+	if c.Scope != "" {
+		return c.Type + "(" + c.Scope + ")"
+	}
+	return c.Type
+}
+
+// Apply corrects the type of a conventional commit message that contradicts the
+// classification and reports whether the message was changed
+func (c Classification) Apply(message string) (string, bool) {
+	header, ok := commitmsg.ParseHeader(message)
+	if !ok {
+		return message, false
+	}
+	for _, accepted := range c.Accepted {
+		if header.Type == accepted {
+			return message, false
+		}
+	}
+
+	header.Type = c.Type
+	if c.Scope != "" {
+		header.Scope = c.Scope
+	}
+
+	_, rest, _ := strings.Cut(message, "\n")
+	if rest != "" {
+		return header.String() + "\n" + rest, true
+	}
+	return header.String(), true
+}
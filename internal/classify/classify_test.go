@@ -0,0 +1,117 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+func modified(path string, lines ...string) git.FileDiff {
+	return git.FileDiff{
+		Path:   path,
+		Status: git.StatusModified,
+		Hunks:  []git.Hunk{{Lines: lines}},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []git.FileDiff
+		wantType string
+		wantOK   bool
+	}{
+		{
+			name:     "tests",
+			files:    []git.FileDiff{modified("store/store_test.go", "+func TestGet(t *testing.T) {}"), modified("store/testdata/in.json", "+{}")},
+			wantType: "test",
+			wantOK:   true,
+		},
+		{
+			name:     "docs",
+			files:    []git.FileDiff{modified("README.md", "+Usage"), modified("docs/guide/setup.txt", "+Step")},
+			wantType: "docs",
+			wantOK:   true,
+		},
+		{
+			name:     "ci",
+			files:    []git.FileDiff{modified(".github/workflows/ci.yml", "+  - run: go vet ./...")},
+			wantType: "ci",
+			wantOK:   true,
+		},
+		{
+			name:     "dependencies",
+			files:    []git.FileDiff{modified("go.mod", "+require golang.org/x/net v0.23.0"), modified("go.sum", "+golang.org/x/net v0.23.0 h1:abc=")},
+			wantType: "chore",
+			wantOK:   true,
+		},
+		{
+			name:     "formatting",
+			files:    []git.FileDiff{modified("main.go", "-func main(){", "-  run()", "+func main() {", "+\trun()")},
+			wantType: "style",
+			wantOK:   true,
+		},
+		{
+			name:   "code change",
+			files:  []git.FileDiff{modified("main.go", "-\trun()", "+\trun(ctx)")},
+			wantOK: false,
+		},
+		{
+			name:   "python indentation",
+			files:  []git.FileDiff{modified("tool.py", "-    run()", "+run()")},
+			wantOK: false,
+		},
+		{
+			name:   "yaml indentation",
+			files:  []git.FileDiff{modified("deploy/values.yaml", "-  image: app", "+image: app")},
+			wantOK: false,
+		},
+		{
+			name:   "mixed",
+			files:  []git.FileDiff{modified("store/store.go", "+func Put() {}"), modified("store/store_test.go", "+func TestPut(t *testing.T) {}")},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := Classify(&git.Diff{Files: tt.files})
+			if ok != tt.wantOK || c.Type != tt.wantType {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.wantType, tt.wantOK, c.Type, ok)
+			}
+		})
+	}
+}
+
+func TestClassification_Apply(t *testing.T) {
+	test, _ := Classify(&git.Diff{Files: []git.FileDiff{modified("a_test.go", "+x")}})
+	deps, _ := Classify(&git.Diff{Files: []git.FileDiff{modified("go.mod", "+x")}})
+
+	tests := []struct {
+		name    string
+		c       Classification
+		message string
+		want    string
+		changed bool
+	}{
+		{"wrong type", test, "feat(store): cover Get\n\nAdds cases.", "test(store): cover Get\n\nAdds cases.", true},
+		{"breaking marker kept", test, "feat!: cover Get", "test!: cover Get", true},
+		{"accepted type", test, "test: cover Get", "test: cover Get", false},
+		{"deps scope added", deps, "feat: bump x/net", "chore(deps): bump x/net", true},
+		{"build accepted for deps", deps, "build(deps): bump x/net", "build(deps): bump x/net", false},
+		{"not conventional", test, "Add tests", "Add tests", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := tt.c.Apply(tt.message)
+			if got != tt.want || changed != tt.changed {
+				t.Errorf("Expected %q (%v), got %q (%v)", tt.want, tt.changed, got, changed)
+			}
+		})
+	}
+
+	if hint := deps.Hint(); hint != "Suggested commit type: chore(deps) (only dependency manifests and lockfiles changed)" {
+		t.Errorf("Unexpected hint: %q", hint)
+	}
+}
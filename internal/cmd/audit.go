@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/audit"
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of provider calls",
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "List recorded provider calls",
+	Long:  `Print the provider calls recorded in the audit log, including rotated logs, oldest first.`,
+	RunE:  runAuditShow,
+}
+
+var (
+	auditFilter audit.Filter
+	auditSince  string
+	auditJSON   bool
+)
+
+func runAuditShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	path, err := auditPath(cfg)
+	if err != nil {
+		return err
+	}
+
+	filter := auditFilter
+	if auditSince != "" {
+		if filter.Since, err = parseSince(auditSince, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	records, err := audit.Read(path)
+	if err != nil {
+		return err
+	}
+
+	if auditJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range records {
+			if filter.Match(r) {
+				if err := enc.Encode(r); err != nil {
+					return fmt.Errorf("failed to write record: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tREPO\tPROVIDER\tENDPOINT\tCOMMIT\tPROMPT\tTOKENS\tREDACTED\tERROR")
+	for _, r := range records {
+		if !filter.Match(r) {
+			continue
+		}
+		repo := r.Remote
+		if repo == "" {
+			repo = r.Repo
+		}
+		commit := r.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d B\t%d/%d\t%d\t%s\n",
+			r.Time.Local().Format("2006-01-02 15:04:05"), repo, r.Provider, orDash(r.Endpoint), orDash(commit),
+			r.PromptBytes, r.PromptTokens, r.CompletionTokens, r.Redactions, r.Error)
+	}
+	return w.Flush()
+}
+
+// auditPath returns the configured audit log path, or the default one when auditing is
+// disabled, so that earlier records can still be inspected
+func auditPath(cfg *config.Config) (string, error) {
+	if cfg.Audit != nil && cfg.Audit.Path != "" {
+		return cfg.Audit.Path, nil
+	}
+	return audit.DefaultPath()
+}
+
+// parseSince accepts a duration before now, such as 72h, or a date in local time
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since value %q: expected a duration such as 72h or a date such as 2006-01-02", s)
+	}
+	return t, nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// auditTrail collects the records of the provider calls made for one commit
+type auditTrail struct {
+	log        *audit.Log
+	records    []audit.Record
+	redactions int
+	committed  bool
+}
+
+// wrap returns provider unchanged when auditing is disabled
+func (t *auditTrail) wrap(provider llm.Provider, cfg *config.Config) llm.Provider {
+	if t == nil || t.log == nil {
+		return provider
+	}
+	return llm.NewAuditingProvider(provider, cfg, t.log.IncludePrompt(), func(r audit.Record) {
+		t.records = append(t.records, r)
+	})
+}
+
+// flush completes the collected records with the repository, the redaction count and the
+// commit hash, if the commit was made, and appends them to the log. A failure to write the
+// log is reported but does not fail the commit.
+func (t *auditTrail) flush(gitRepo *git.Repository) {
+	if t.log == nil || len(t.records) == 0 {
+		return
+	}
+
+	root, _ := gitRepo.TopLevel()
+	var remote string
+	if remotes, err := gitRepo.RemoteURLs(); err == nil && len(remotes) > 0 {
+		remote = remotes[0]
+		// Credentials embedded in an HTTPS remote must not end up in the log
+		if u, err := url.Parse(remote); err == nil && u.User != nil {
+			u.User = nil
+			remote = u.String()
+		}
+	}
+	var commit string
+	if t.committed {
+		commit, _ = gitRepo.HeadCommit()
+	}
+
+	for i := range t.records {
+		t.records[i].Repo = root
+		t.records[i].Remote = remote
+		t.records[i].Commit = commit
+		t.records[i].Redactions = t.redactions
+	}
+	if err := t.log.Append(t.records...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
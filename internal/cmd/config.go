@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration file",
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the API keys and tokens in the configuration file",
+	Long: `Encrypt the secrets in the configuration file with a passphrase, read from
+$GIT_AUTO_COMMIT_PASSPHRASE or prompted for, or with --key for the public key of a key file,
+which is created if it does not exist. The other settings stay readable.`,
+	RunE: runConfigEncrypt,
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Store the secrets in the configuration file in plaintext again",
+	RunE:  runConfigDecrypt,
+}
+
+var (
+	encryptKey      bool
+	encryptIdentity string
+)
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	path, cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if encryptKey || encryptIdentity != "" {
+		identity := encryptIdentity
+		if identity == "" {
+			if identity, err = config.DefaultIdentityPath(); err != nil {
+				return err
+			}
+		}
+		// The path is stored in the configuration, so it must not depend on the working directory
+		if identity, err = filepath.Abs(identity); err != nil {
+			return fmt.Errorf("failed to resolve key file path: %w", err)
+		}
+		if _, err := os.Stat(identity); errors.Is(err, os.ErrNotExist) {
+			if err := config.GenerateIdentity(identity); err != nil {
+				return err
+			}
+			fmt.Printf("Created key file %s; keep a backup, the secrets cannot be decrypted without it\n", identity)
+		}
+		if err := cfg.EncryptForIdentity(identity); err != nil {
+			return err
+		}
+	} else {
+		passphrase, err := newPassphrase()
+		if err != nil {
+			return err
+		}
+		if err := cfg.EncryptWithPassphrase(passphrase); err != nil {
+			return err
+		}
+	}
+
+	if err := config.Save(cfg, path); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	fmt.Printf("✓ Secrets in %s encrypted with a %s\n", path, cfg.Encryption.Method)
+	return nil
+}
+
+func runConfigDecrypt(cmd *cobra.Command, args []string) error {
+	path, cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	if cfg.Encryption == nil {
+		return fmt.Errorf("the secrets in %s are not encrypted", path)
+	}
+
+	cfg.Encryption = nil
+	if err := config.Save(cfg, path); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	fmt.Printf("✓ Secrets in %s stored in plaintext\n", path)
+	return nil
+}
+
+// loadConfigFile loads the configuration from --config or the default path, returning the path
+// it is saved back to
+func loadConfigFile() (string, *config.Config, error) {
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return path, cfg, nil
+}
+
+// newPassphrase reads the passphrase to encrypt with from the environment, or prompts for it twice
+func newPassphrase() (string, error) {
+	if passphrase := os.Getenv(config.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readPassphrase prompts on the terminal and reads a line with echo turned off. Without a
+// terminal, such as in hooks, the passphrase must come from the environment.
+func readPassphrase(prompt string) (string, error) {
+	notTerminal := fmt.Errorf("the config file secrets are encrypted with a passphrase: set %s", config.PassphraseEnv)
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", notTerminal
+	}
+
+	// stty fails when stdin is not a terminal; Windows has no stty, so the input is echoed there
+	if err := setEcho(false); err == nil {
+		defer func() {
+			setEcho(true)
+			fmt.Fprintln(os.Stderr)
+		}()
+	} else if runtime.GOOS != "windows" {
+		return "", notTerminal
+	}
+	fmt.Fprint(os.Stderr, prompt)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func setEcho(on bool) error {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	stty := exec.Command("stty", arg)
+	stty.Stdin = os.Stdin
+	return stty.Run()
+}
@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -46,18 +50,14 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to read API key: %w", err)
 		}
-		cfg.OpenAI = &config.OpenAIConfig{
-			APIKey: strings.TrimSpace(apiKey),
-			Model:  "gpt-4",
-		}
-		fmt.Print("Enter model (default: gpt-4): ")
-		model, err := reader.ReadString('\n')
+		apiKey = strings.TrimSpace(apiKey)
+		model, err := chooseModel(reader, llm.NewOpenAIProvider(apiKey, ""), "model", "gpt-4o")
 		if err != nil {
-			return fmt.Errorf("failed to read model: %w", err)
+			return err
 		}
-		model = strings.TrimSpace(model)
-		if model != "" {
-			cfg.OpenAI.Model = model
+		cfg.OpenAI = &config.OpenAIConfig{
+			APIKey: apiKey,
+			Model:  model,
 		}
 
 	case "2":
@@ -72,16 +72,17 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to read API key: %w", err)
 		}
-		fmt.Print("Enter Deployment Name: ")
-		deployment, err := reader.ReadString('\n')
+		endpoint = strings.TrimSpace(endpoint)
+		apiKey = strings.TrimSpace(apiKey)
+		deployment, err := chooseModel(reader, llm.NewAzureOpenAIProvider(endpoint, apiKey, ""), "deployment", "")
 		if err != nil {
-			return fmt.Errorf("failed to read deployment: %w", err)
+			return err
 		}
 
 		cfg.Azure = &config.AzureOpenAIConfig{
-			Endpoint:   strings.TrimSpace(endpoint),
-			APIKey:     strings.TrimSpace(apiKey),
-			Deployment: strings.TrimSpace(deployment),
+			Endpoint:   endpoint,
+			APIKey:     apiKey,
+			Deployment: deployment,
 		}
 
 	case "3":
@@ -91,39 +92,31 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to read API key: %w", err)
 		}
-		cfg.Claude = &config.ClaudeConfig{
-			APIKey: strings.TrimSpace(apiKey),
-			Model:  "claude-3-5-sonnet-20241022",
-		}
-		fmt.Print("Enter model (default: claude-3-5-sonnet-20241022): ")
-		model, err := reader.ReadString('\n')
+		apiKey = strings.TrimSpace(apiKey)
+		model, err := chooseModel(reader, llm.NewClaudeProvider(apiKey, ""), "model", "claude-3-5-sonnet-20241022")
 		if err != nil {
-			return fmt.Errorf("failed to read model: %w", err)
+			return err
 		}
-		model = strings.TrimSpace(model)
-		if model != "" {
-			cfg.Claude.Model = model
+		cfg.Claude = &config.ClaudeConfig{
+			APIKey: apiKey,
+			Model:  model,
 		}
 
 	case "4":
 		cfg.Provider = "github"
-		fmt.Print("Enter GitHub Token: ")
+		fmt.Print("Enter GitHub Token (leave empty to use GITHUB_TOKEN, GH_TOKEN or the gh CLI): ")
 		token, err := reader.ReadString('\n')
 		if err != nil {
 			return fmt.Errorf("failed to read token: %w", err)
 		}
-		cfg.GitHub = &config.GitHubConfig{
-			Token: strings.TrimSpace(token),
-			Model: "gpt-4o",
-		}
-		fmt.Print("Enter model (default: gpt-4o): ")
-		model, err := reader.ReadString('\n')
+		token = strings.TrimSpace(token)
+		model, err := chooseModel(reader, llm.NewGitHubProvider(token, ""), "model", "gpt-4o")
 		if err != nil {
-			return fmt.Errorf("failed to read model: %w", err)
+			return err
 		}
-		model = strings.TrimSpace(model)
-		if model != "" {
-			cfg.GitHub.Model = model
+		cfg.GitHub = &config.GitHubConfig{
+			Token: token,
+			Model: model,
 		}
 
 	default:
@@ -133,11 +126,10 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 	// Save configuration
 	path := configPath
 	if path == "" {
-		home, err := os.UserHomeDir()
+		path, err = config.DefaultPath()
 		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+			return err
 		}
-		path = home + "/.git-auto-commit.yaml"
 	}
 
 	if err := config.Save(cfg, path); err != nil {
@@ -145,5 +137,61 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("\n✓ Configuration saved to %s\n", path)
+	if cfg.HasPlaintextSecrets() {
+		fmt.Println("Run 'git-auto-commit config encrypt' to encrypt the stored credentials")
+	}
 	return nil
 }
+
+// chooseModel offers the models reported by the provider as a pick list, falling back to
+// free-text entry when they cannot be listed
+func chooseModel(reader *bufio.Reader, lister llm.ModelLister, kind, defaultModel string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		fmt.Printf("Could not list available %ss: %v\n", kind, err)
+	}
+
+	if len(models) > 0 {
+		found := false
+		for _, m := range models {
+			if m.ID == defaultModel {
+				found = true
+				break
+			}
+		}
+		if !found {
+			defaultModel = models[0].ID
+		}
+
+		fmt.Printf("\nAvailable %ss:\n", kind)
+		for i, m := range models {
+			fmt.Printf("%d. %s", i+1, m.ID)
+			if m.ContextWindow > 0 {
+				fmt.Printf(" (%d tokens)", m.ContextWindow)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("Enter choice (1-%d) or %s name (default: %s): ", len(models), kind, defaultModel)
+	} else if defaultModel != "" {
+		fmt.Printf("Enter %s (default: %s): ", kind, defaultModel)
+	} else {
+		fmt.Printf("Enter %s name: ", kind)
+	}
+
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", kind, err)
+	}
+	input = strings.TrimSpace(input)
+
+	if input == "" {
+		return defaultModel, nil
+	}
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(models) {
+		return models[n-1].ID, nil
+	}
+	return input, nil
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, git and provider connectivity",
+	Long: `Run a series of checks on the configuration file, the selected provider,
+the git environment and connectivity to the provider, and print a diagnostic report.`,
+	RunE:         runDoctor,
+	SilenceUsage: true,
+}
+
+// hookNames are the git hooks git-auto-commit can be wired into
+var hookNames = []string{"prepare-commit-msg", "commit-msg"}
+
+// doctorReport collects the outcome of each diagnostic check
+type doctorReport struct {
+	failures int
+}
+
+func (r *doctorReport) pass(format string, args ...any) {
+	fmt.Printf("✓ "+format+"\n", args...)
+}
+
+func (r *doctorReport) warn(format string, args ...any) {
+	fmt.Printf("! "+format+"\n", args...)
+}
+
+func (r *doctorReport) fail(format string, args ...any) {
+	r.failures++
+	fmt.Printf("✗ "+format+"\n", args...)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	report := &doctorReport{}
+
+	cfg := checkConfig(report)
+	checkGit(report)
+	if cfg != nil {
+		checkPolicy(report, cfg)
+		checkProvider(cmd.Context(), report, cfg)
+	}
+
+	if report.failures > 0 {
+		return fmt.Errorf("%d check(s) failed", report.failures)
+	}
+
+	fmt.Println("\nAll checks passed")
+	return nil
+}
+
+// checkConfig verifies the configuration file and the block for the selected provider
+func checkConfig(report *doctorReport) *config.Config {
+	path := configPath
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			report.fail("Config file: %v", err)
+			return nil
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		report.fail("Config file: %s not found (run 'git-auto-commit configure')", path)
+		return nil
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		report.warn("Config file: %s has permissions %o, expected 600 (run 'chmod 600 %s')", path, info.Mode().Perm(), path)
+	} else {
+		report.pass("Config file: %s", path)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		report.fail("Config file: %v", err)
+		return nil
+	}
+
+	switch {
+	case cfg.Encryption != nil:
+		report.pass("Secrets: encrypted with a %s", cfg.Encryption.Method)
+	case cfg.HasPlaintextSecrets():
+		report.warn("Secrets: stored in plaintext (run 'git-auto-commit config encrypt')")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		report.fail("Provider configuration: %v", err)
+		return nil
+	}
+	report.pass("Provider configuration: %s is complete", cfg.Provider)
+
+	return cfg
+}
+
+// checkGit verifies that git is installed, the current directory is a work tree
+// and reports any git-auto-commit hooks
+func checkGit(report *doctorReport) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		report.fail("Git: executable not found in PATH")
+		return
+	}
+	report.pass("Git: %s", gitPath)
+
+	gitRepo := git.NewRepository(".")
+	if err := gitRepo.CheckWorkTree(); err != nil {
+		report.fail("Repository: %v", err)
+		return
+	}
+	report.pass("Repository: inside a git work tree")
+
+	hooksDir, err := gitRepo.HooksDir()
+	if err != nil {
+		report.warn("Hooks: %v", err)
+		return
+	}
+
+	var installed []string
+	for _, name := range hookNames {
+		content, err := os.ReadFile(filepath.Join(hooksDir, name))
+		if err == nil && strings.Contains(string(content), "git-auto-commit") {
+			installed = append(installed, name)
+		}
+	}
+	if len(installed) == 0 {
+		report.pass("Hooks: no git-auto-commit hooks installed in %s", hooksDir)
+	} else {
+		report.pass("Hooks: %s installed in %s", strings.Join(installed, ", "), hooksDir)
+	}
+}
+
+// checkPolicy applies the organization policy for the current repository and reports its restrictions
+func checkPolicy(report *doctorReport, cfg *config.Config) {
+	if len(config.PolicyPaths()) == 0 {
+		return
+	}
+	if err := applyPolicy(cfg, git.NewRepository(".")); err != nil {
+		report.fail("Policy: %v", err)
+		return
+	}
+	if cfg.Policy == nil {
+		report.pass("Policy: no rule in the organization policy applies to this repository")
+		return
+	}
+
+	var restrictions []string
+	if cfg.Policy.AllowedProviders != nil {
+		restrictions = append(restrictions, "providers "+strings.Join(cfg.Policy.AllowedProviders, ", "))
+	}
+	for _, endpoints := range cfg.Policy.AllowedEndpoints {
+		restrictions = append(restrictions, "endpoints "+strings.Join(endpoints, ", "))
+	}
+	if cfg.Policy.Privacy != "" {
+		restrictions = append(restrictions, "privacy "+cfg.Policy.Privacy)
+	}
+	if cfg.Policy.RequireRedaction {
+		restrictions = append(restrictions, "redaction required")
+	}
+	if cfg.Policy.MaxDiffBytes > 0 {
+		restrictions = append(restrictions, fmt.Sprintf("at most %d bytes per prompt", cfg.Policy.MaxDiffBytes))
+	}
+	if len(restrictions) == 0 {
+		restrictions = append(restrictions, "no restrictions")
+	}
+	report.pass("Policy: %s applies %s", cfg.Policy.Source, strings.Join(restrictions, "; "))
+}
+
+// checkProvider sends a minimal authenticated request to the selected provider and reports its latency
+func checkProvider(ctx context.Context, report *doctorReport, cfg *config.Config) {
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		report.fail("Provider: %v", err)
+		return
+	}
+
+	lister, ok := provider.(llm.ModelLister)
+	if !ok {
+		report.pass("Provider: %s does not use the network", cfg.Provider)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	models, err := lister.ListModels(ctx)
+	if checker, ok := provider.(llm.AuthChecker); ok && err == nil {
+		err = checker.CheckAuth(ctx)
+	}
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		report.fail("Provider: request to %s failed after %s: %v", cfg.Provider, latency, err)
+		return
+	}
+
+	report.pass("Provider: authenticated request to %s succeeded in %s (%d models available)", cfg.Provider, latency, len(models))
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to GitHub Models with the OAuth device flow",
+	Long: `Authorize git-auto-commit with GitHub using the OAuth device flow and store
+the resulting token in the github section of the configuration file.`,
+	RunE: runLogin,
+}
+
+var (
+	loginClientID  string
+	loginGitHubURL string
+	loginScope     string
+)
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	if loginClientID == "" {
+		loginClientID = os.Getenv("GIT_AUTO_COMMIT_GITHUB_CLIENT_ID")
+	}
+	if loginClientID == "" {
+		return fmt.Errorf("an OAuth app client ID is required: pass --client-id or set GIT_AUTO_COMMIT_GITHUB_CLIENT_ID")
+	}
+
+	path := configPath
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		cfg = &config.Config{Provider: "github"}
+	} else if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	flow := llm.NewGitHubDeviceFlow(loginGitHubURL, loginClientID, loginScope)
+	code, err := flow.RequestCode(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Open %s and enter the code: %s\n", code.VerificationURI, code.UserCode)
+	fmt.Println("Waiting for authorization...")
+
+	token, err := flow.PollToken(cmd.Context(), code)
+	if err != nil {
+		return err
+	}
+
+	if cfg.GitHub == nil {
+		cfg.GitHub = &config.GitHubConfig{Model: "gpt-4o"}
+	}
+	cfg.GitHub.Token = token
+
+	if err := config.Save(cfg, path); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("\n✓ GitHub token saved to %s\n", path)
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List available models per provider",
+	Long:  `Query the model listing endpoint of each configured provider and print the model IDs with their context window where known.`,
+	RunE:  runModels,
+}
+
+var modelsProvider string
+
+func runModels(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	providers := cfg.ConfiguredProviders()
+	if modelsProvider != "" {
+		providers = []string{modelsProvider}
+	}
+	if len(providers) == 0 {
+		return fmt.Errorf("no providers configured")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	failed := 0
+	for _, name := range providers {
+		fmt.Printf("%s:\n", name)
+		models, err := listModels(ctx, cfg, name)
+		if err != nil {
+			if len(providers) == 1 {
+				return err
+			}
+			fmt.Printf("  error: %v\n", err)
+			failed++
+			continue
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, m := range models {
+			fmt.Fprintf(w, "  %s\t%s\n", m.ID, formatContextWindow(m.ContextWindow))
+		}
+		w.Flush()
+	}
+
+	if failed == len(providers) {
+		return fmt.Errorf("failed to list models for any provider")
+	}
+	return nil
+}
+
+// listModels queries the model listing endpoint of the named provider using its configuration block
+func listModels(ctx context.Context, cfg *config.Config, name string) ([]llm.Model, error) {
+	providerCfg := *cfg
+	providerCfg.Provider = name
+
+	provider, err := llm.NewProvider(&providerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI provider: %w", err)
+	}
+
+	lister, ok := provider.(llm.ModelLister)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support listing models", name)
+	}
+
+	return lister.ListModels(ctx)
+}
+
+// formatContextWindow renders a context window size, or "-" when it is unknown
+func formatContextWindow(tokens int) string {
+	if tokens == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d tokens", tokens)
+}
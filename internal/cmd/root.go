@@ -1,11 +1,26 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/algernon-coop/git-auto-commit/internal/audit"
+	"github.com/algernon-coop/git-auto-commit/internal/classify"
+	"github.com/algernon-coop/git-auto-commit/internal/commitmsg"
 	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/deps"
+	"github.com/algernon-coop/git-auto-commit/internal/exclude"
 	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/goapi"
+	"github.com/algernon-coop/git-auto-commit/internal/grounding"
 	"github.com/algernon-coop/git-auto-commit/internal/llm"
+	"github.com/algernon-coop/git-auto-commit/internal/mechanical"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+	"github.com/algernon-coop/git-auto-commit/internal/privacy"
+	"github.com/algernon-coop/git-auto-commit/internal/redact"
+	"github.com/algernon-coop/git-auto-commit/internal/safety"
 	"github.com/spf13/cobra"
 )
 
@@ -14,19 +29,56 @@ var rootCmd = &cobra.Command{
 	Short: "Automatically generate commit messages using AI",
 	Long: `git-auto-commit is a tool that generates meaningful commit messages
 based on your staged changes using various AI providers (OpenAI, Claude, GitHub models).`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			llm.DebugOutput = os.Stderr
+		}
+		config.PassphrasePrompt = func() (string, error) {
+			return readPassphrase("Config passphrase: ")
+		}
+	},
 	RunE: runGenerate,
 }
 
 var (
 	configPath string
 	dryRun     bool
+	recordPath string
+	skipChecks bool
+	verbose    bool
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "config file path (default: $HOME/.git-auto-commit.yaml)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "print debug information")
 	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "generate commit message without committing")
+	rootCmd.Flags().BoolVar(&skipChecks, "skip-checks", false, "commit even if the staged content or generated message fails the safety checks")
+	rootCmd.Flags().StringVar(&recordPath, "record", "", "record provider requests and responses to a cassette file for the replay provider")
+
+	modelsCmd.Flags().StringVarP(&modelsProvider, "provider", "p", "", "only list models for this provider")
+
+	loginCmd.Flags().StringVar(&loginClientID, "client-id", "", "OAuth app client ID (default: $GIT_AUTO_COMMIT_GITHUB_CLIENT_ID)")
+	loginCmd.Flags().StringVar(&loginGitHubURL, "github-url", llm.DefaultGitHubURL, "GitHub web URL hosting the device flow endpoints")
+	loginCmd.Flags().StringVar(&loginScope, "scope", "", "OAuth scopes to request")
+
+	auditShowCmd.Flags().StringVar(&auditFilter.Repo, "repo", "", "only show calls for repositories whose path or remote contains this")
+	auditShowCmd.Flags().StringVar(&auditFilter.Provider, "provider", "", "only show calls to this provider")
+	auditShowCmd.Flags().StringVar(&auditFilter.Commit, "commit", "", "only show calls for commits starting with this hash")
+	auditShowCmd.Flags().StringVar(&auditSince, "since", "", "only show calls after a duration ago (such as 72h) or a date (2006-01-02)")
+	auditShowCmd.Flags().BoolVar(&auditJSON, "json", false, "print the matching records as JSON lines")
+	auditCmd.AddCommand(auditShowCmd)
+
+	configEncryptCmd.Flags().BoolVar(&encryptKey, "key", false, "encrypt for a key file instead of a passphrase (default: $HOME/.git-auto-commit.key)")
+	configEncryptCmd.Flags().StringVar(&encryptIdentity, "identity", "", "key file to encrypt for, created if it does not exist; implies --key")
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
 
 	rootCmd.AddCommand(configureCmd)
+	rootCmd.AddCommand(modelsCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(configCmd)
 }
 
 func Execute() error {
@@ -40,29 +92,121 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Get staged changes
+	// The organization policy overrides the user configuration
 	gitRepo := git.NewRepository(".")
+	if err := applyPolicy(cfg, gitRepo); err != nil {
+		return err
+	}
+
+	// Provider calls are recorded once the outcome of the commit is known
+	auditLog, err := audit.New(cfg.Audit)
+	if err != nil {
+		return fmt.Errorf("failed to configure audit log: %w", err)
+	}
+	trail := &auditTrail{log: auditLog}
+	defer trail.flush(gitRepo)
+
+	// Get staged changes
 	diff, err := gitRepo.GetStagedDiff()
 	if err != nil {
 		return fmt.Errorf("failed to get staged changes: %w", err)
 	}
 
-	if diff == "" {
+	if len(diff.Files) == 0 {
 		return fmt.Errorf("no staged changes found")
 	}
 
-	// Get repository commit guidelines
-	guidelines := gitRepo.GetCommitGuidelines()
+	// Check staged content before anything is generated or committed
+	if cfg.Checks == nil || !cfg.Checks.Disabled {
+		if err := checkStaged(cfg, gitRepo, diff); err != nil {
+			return err
+		}
+	}
+
+	// A repository can require a stricter privacy mode than the user configuration
+	repoPrivacy, err := gitRepo.ConfigValue(privacy.GitConfigKey)
+	if err != nil {
+		return err
+	}
+	mode, err := privacy.Strictest(cfg.Privacy, repoPrivacy)
+	if err != nil {
+		return fmt.Errorf("invalid privacy setting: %w", err)
+	}
 
-	// Generate commit message
-	provider, err := llm.NewProvider(cfg)
+	// The heuristic provider runs locally and describes every staged file, so it always gets
+	// the whole diff rather than a prompt with files summarised
+	var diffText string
+	switch {
+	case cfg.Provider == "heuristic":
+		diffText = diff.Raw
+	case mode == privacy.Metadata:
+		diffText, err = metadataPrompt(gitRepo, diff)
+	default:
+		diffText, err = fullPrompt(gitRepo, cfg, diff)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create AI provider: %w", err)
+		return err
+	}
+
+	// Breaking API changes are detected on the full diff, including generated code
+	breaking := goapi.DiffBreakingChanges(gitRepo, diff)
+	if summary := goapi.BreakingSummary(breaking); summary != "" {
+		diffText = summary + "\n\n" + diffText
+	}
+
+	// Dependency updates are read from the manifests rather than left to the model
+	updates := deps.Detect(gitRepo, diff)
+	if summary := deps.Summary(updates); summary != "" && mode != privacy.Metadata {
+		diffText = summary + "\n\n" + diffText
+	}
+
+	// Changes confined to tests, docs, CI or dependencies have an obvious commit type
+	classification, classified := classify.Classify(diff)
+	if classified {
+		diffText = classification.Hint() + "\n\n" + diffText
 	}
 
-	message, err := provider.GenerateCommitMessage(cmd.Context(), diff, guidelines)
+	// Redaction comes last so that it covers the summaries as well as the diff
+	diffText, trail.redactions, err = redactPrompt(cfg, diffText)
 	if err != nil {
-		return fmt.Errorf("failed to generate commit message: %w", err)
+		return err
+	}
+
+	var message string
+	if deps.OnlyUpdates(diff, updates) {
+		// A change that only updates dependencies gets a Dependabot-style message without a provider call.
+		// Any other manifest edit is left to the model, with the updates in the prompt.
+		message = deps.Message(updates)
+	} else {
+		guidelines := gitRepo.GetCommitGuidelines()
+		message, err = generateMessage(cmd.Context(), cfg, trail, diffText, guidelines)
+		if err != nil {
+			return err
+		}
+
+		// Names in the message must exist in the diff or the files it touches
+		if cfg.Checks == nil || !cfg.Checks.Disabled {
+			sources := append([]string{diff.Raw, diffText}, grounding.FileSources(gitRepo, diff)...)
+			message = groundMessage(cmd.Context(), cfg, trail, grounding.New(sources...), message, diffText, guidelines)
+		}
+	}
+
+	if classified {
+		if corrected, changed := classification.Apply(message); changed {
+			fmt.Printf("Corrected the commit type to %s: %s\n\n", classification.Type, classification.Reason)
+			message = corrected
+		}
+	}
+	if len(breaking) > 0 {
+		message = commitmsg.MarkBreaking(message, goapi.BreakingFooter(breaking))
+	}
+
+	// The diff is untrusted, so look for content it may have injected into the message
+	// before the message is shown or committed
+	if cfg.Checks == nil || !cfg.Checks.Disabled {
+		if err := checkMessage(message, diff, cfg.Checks != nil && cfg.Checks.BlockMessage); err != nil {
+			return err
+		}
 	}
 
 	fmt.Println("Generated commit message:")
@@ -78,7 +222,265 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	if err := gitRepo.Commit(message); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
+	trail.committed = true
 
 	fmt.Println("✓ Changes committed successfully")
 	return nil
 }
+
+// fullPrompt builds the diff sent to the provider, with excluded files and mechanical changes
+// summarised and an outline of the changed Go declarations
+func fullPrompt(gitRepo *git.Repository, cfg *config.Config, diff *git.Diff) (string, error) {
+	// Summarise lockfiles, generated and excluded files and mechanical changes instead of sending their hunks
+	prompt, omitted, patterns, err := promptDiff(gitRepo, cfg, diff)
+	if err != nil {
+		return "", err
+	}
+	diffText := prompt.Raw
+
+	// Summaries go ahead of the diff, where redaction checks them as plain text
+	if summary := mechanical.Summary(patterns); summary != "" {
+		diffText = strings.TrimSpace(summary + "\n\n" + diffText)
+	}
+	if summary := exclude.Summary(omitted); summary != "" {
+		diffText = strings.TrimSpace(summary + "\n\n" + diffText)
+	}
+
+	// Give the model an outline of the changed Go declarations ahead of the text diff
+	if summary := goapi.Summary(goapi.DiffChanges(gitRepo, prompt)); summary != "" {
+		diffText = summary + "\n\n" + diffText
+	}
+	return diffText, nil
+}
+
+// redactPrompt removes secrets from the complete prompt before it leaves the machine and
+// returns the number of redacted items
+func redactPrompt(cfg *config.Config, diffText string) (string, int, error) {
+	if cfg.Redaction != nil && cfg.Redaction.Disabled {
+		return diffText, 0, nil
+	}
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to configure redaction: %w", err)
+	}
+	diffText, findings := redactor.Redact(diffText)
+	if len(findings) > 0 {
+		fmt.Printf("Redacted %d item(s) before sending the prompt:\n%s\n\n", len(findings), indent(redact.Summary(findings)))
+	}
+	return diffText, len(findings), nil
+}
+
+// metadataPrompt describes the change by its paths, statistics, Go symbol names and branch, without any code
+func metadataPrompt(gitRepo *git.Repository, diff *git.Diff) (string, error) {
+	branch, err := gitRepo.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
+	fmt.Println("Privacy mode is metadata: only file paths, change statistics and symbol names are sent")
+	fmt.Println()
+	return privacy.Prompt(branch, diff, goapi.DiffChanges(gitRepo, diff)), nil
+}
+
+// applyPolicy loads the organization policy files, if there are any, and applies the rules matching
+// the repository's root directory or remotes to the configuration
+func applyPolicy(cfg *config.Config, gitRepo *git.Repository) error {
+	paths := config.PolicyPaths()
+	if len(paths) == 0 {
+		return nil
+	}
+	policy, err := config.LoadPolicies(paths...)
+	if err != nil {
+		return err
+	}
+
+	root, err := gitRepo.TopLevel()
+	if err != nil {
+		return err
+	}
+	remotes, err := gitRepo.RemoteURLs()
+	if err != nil {
+		return err
+	}
+
+	cfg.ApplyPolicy(policy.Restrictions(root, remotes))
+	return nil
+}
+
+// generateMessage asks the configured provider for a commit message
+func generateMessage(ctx context.Context, cfg *config.Config, trail *auditTrail, diffText, guidelines string) (string, error) {
+	// The heuristic provider sends nothing, so the size limit does not apply to it
+	if cfg.Policy != nil && cfg.Policy.MaxDiffBytes > 0 && len(diffText) > cfg.Policy.MaxDiffBytes && cfg.Provider != "heuristic" {
+		return "", fmt.Errorf("the prompt is %d bytes, over the %d byte limit set by the policy in %s; stage fewer changes",
+			len(diffText), cfg.Policy.MaxDiffBytes, cfg.Policy.Source)
+	}
+
+	var provider llm.Provider
+	var err error
+	if recordPath != "" {
+		provider, err = llm.NewRecordingProvider(cfg, recordPath)
+	} else {
+		provider, err = llm.NewProvider(cfg)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI provider: %w", err)
+	}
+	provider = trail.wrap(provider, cfg)
+
+	message, err := provider.GenerateCommitMessage(ctx, diffText, guidelines)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	return message, nil
+}
+
+// groundMessage regenerates a message that mentions names found nowhere in the change, once,
+// and warns about the names that remain
+func groundMessage(ctx context.Context, cfg *config.Config, trail *auditTrail, checker *grounding.Checker, message, diffText, guidelines string) string {
+	missing := checker.Check(message)
+	if len(missing) == 0 {
+		return message
+	}
+
+	fmt.Printf("The message mentions names not found in the change (%s); regenerating\n\n", strings.Join(missing, ", "))
+	// The feedback comes from the checker, not the repository, so it is sent as a trusted
+	// instruction rather than with the untrusted guidelines
+	note := "Only mention functions, types, variables and files that appear in the diff. These do not: " + strings.Join(missing, ", ")
+	retry, err := generateMessage(llm.WithInstruction(ctx, note), cfg, trail, diffText, guidelines)
+	if err != nil {
+		fmt.Printf("Warning: %v; keeping the first message\n\n", err)
+	} else if remaining := checker.Check(retry); len(remaining) < len(missing) {
+		message, missing = retry, remaining
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("Warning: the message mentions names not found in the change: %s\n\n", strings.Join(missing, ", "))
+	}
+	return message
+}
+
+// checkStaged runs the safety gate on the staged files and returns an error if the
+// commit should be blocked. Issues are only reported in dry-run mode or with --skip-checks.
+func checkStaged(cfg *config.Config, gitRepo *git.Repository, diff *git.Diff) error {
+	gate, err := safety.New(cfg.Checks, cfg.Redaction)
+	if err != nil {
+		return fmt.Errorf("failed to configure safety checks: %w", err)
+	}
+
+	paths := make([]string, len(diff.Files))
+	for i, f := range diff.Files {
+		paths[i] = f.Path
+	}
+	sizes, err := gitRepo.StagedSizes(paths...)
+	if err != nil {
+		return err
+	}
+
+	issues := gate.Check(diff, sizes)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Staged changes failed %d safety check(s):\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", issue)
+	}
+	fmt.Println()
+
+	if skipChecks || dryRun {
+		return nil
+	}
+	return fmt.Errorf("commit blocked by safety checks; fix the issues or rerun with --skip-checks")
+}
+
+// checkMessage reports URLs and shell commands in the generated message that are not in the
+// lines added by the diff. The issues are a warning unless block is set, in which case an
+// error is returned if the commit should be blocked, like checkStaged, and the message is not printed.
+func checkMessage(message string, diff *git.Diff, block bool) error {
+	issues := safety.CheckMessage(message, diff)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if block {
+		fmt.Printf("\nGenerated message failed %d output check(s):\n", len(issues))
+	} else {
+		fmt.Printf("\nWarning: generated message failed %d output check(s); review it before pushing:\n", len(issues))
+	}
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", issue)
+	}
+	fmt.Println()
+
+	if !block || skipChecks || dryRun {
+		return nil
+	}
+	return fmt.Errorf("commit blocked by output checks; rerun with --dry-run to review the message or --skip-checks to commit it")
+}
+
+// promptDiff returns the diff sent to the provider without the files that are only summarised:
+// excluded files, and files whose changes repeat a mechanical pattern across many files
+func promptDiff(gitRepo *git.Repository, cfg *config.Config, diff *git.Diff) (*git.Diff, []exclude.Omitted, []mechanical.Pattern, error) {
+	patterns := exclude.DefaultPatterns
+	minFiles := mechanical.DefaultMinFiles
+	if cfg.Diff != nil {
+		if err := pathmatch.Validate(cfg.Diff.Exclude); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid diff exclude pattern: %w", err)
+		}
+		patterns = append(append([]string{}, patterns...), cfg.Diff.Exclude...)
+		if cfg.Diff.MechanicalMinFiles != 0 {
+			minFiles = cfg.Diff.MechanicalMinFiles
+		}
+	}
+
+	paths := make([]string, len(diff.Files))
+	for i, f := range diff.Files {
+		paths[i] = f.Path
+	}
+	attrs, err := gitRepo.Attributes(paths, exclude.Attributes...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get git attributes: %w", err)
+	}
+
+	omitted := exclude.Select(diff.Files, patterns, attrs)
+	excluded := exclude.Paths(omitted)
+
+	var mechanicalPatterns []mechanical.Pattern
+	if minFiles > 0 {
+		// Omitted files are already summarised, so they do not count towards a pattern
+		isOmitted := map[string]bool{}
+		for _, p := range excluded {
+			isOmitted[p] = true
+		}
+		var remaining git.Diff
+		for _, f := range diff.Files {
+			if !isOmitted[f.Path] {
+				remaining.Files = append(remaining.Files, f)
+			}
+		}
+		// Files with secrets are left in the diff, where they are redacted, rather than summarised
+		var redactor *redact.Redactor
+		if cfg.Redaction == nil || !cfg.Redaction.Disabled {
+			redactor, err = redact.New(cfg.Redaction)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to configure redaction: %w", err)
+			}
+		}
+		mechanicalPatterns = mechanical.Detect(&remaining, minFiles, redactor)
+		excluded = append(excluded, mechanical.Paths(mechanicalPatterns)...)
+	}
+
+	if len(excluded) == 0 {
+		return diff, nil, nil, nil
+	}
+
+	diff, err = gitRepo.GetStagedDiff(excluded...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	return diff, omitted, mechanicalPatterns, nil
+}
+
+// indent prefixes every line of s with two spaces
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
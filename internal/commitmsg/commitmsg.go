@@ -0,0 +1,75 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headerPattern matches a conventional commit header: type, optional scope, optional "!" and description
+var headerPattern = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?: (.*)$`)
+
+// Header is the parsed first line of a conventional commit message
+type Header struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+// ParseHeader parses the first line of a message, reporting false if it is not a conventional commit header
+func ParseHeader(message string) (Header, bool) {
+	subject, _, _ := strings.Cut(message, "\n")
+	m := headerPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return Header{}, false
+	}
+	return Header{
+		Type:        m[1],
+		Scope:       strings.Trim(m[2], "()"),
+		Breaking:    m[3] == "!",
+		Description: m[4],
+	}, true
+}
+
+// String formats the header as a conventional commit subject line
+func (h Header) String() string {
+	s := h.Type
+	if h.Scope != "" {
+		s += "(" + h.Scope + ")"
+	}
+	if h.Breaking {
+		s += "!"
+	}
+	return s + ": " + h.Description
+}
+
+// MarkBreaking adds the "!" marker to a conventional commit header and appends a
+// BREAKING CHANGE footer with the given description unless the message already has one
+func MarkBreaking(message, description string) string {
+	message = strings.TrimSpace(message)
+	subject, rest, _ := strings.Cut(message, "\n")
+
+	if header, ok := ParseHeader(subject); ok {
+		header.Breaking = true
+		subject = header.String()
+	}
+
+	message = subject
+	if rest != "" {
+		message += "\n" + rest
+	}
+
+	if hasBreakingFooter(rest) {
+		return message
+	}
+	return message + "\n\nBREAKING CHANGE: " + description
+}
+
+func hasBreakingFooter(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package commitmsg
+
+import "testing"
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Header
+		ok      bool
+	}{
+		{"feat: add login", Header{Type: "feat", Description: "add login"}, true},
+		{"fix(api)!: drop v1 routes\n\nbody", Header{Type: "fix", Scope: "api", Breaking: true, Description: "drop v1 routes"}, true},
+		{"Update README", Header{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseHeader(tt.message)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseHeader(%q) = %+v, %v, want %+v, %v", tt.message, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMarkBreaking(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "subject only",
+			message: "feat(store): return found flag from Get",
+			want:    "feat(store)!: return found flag from Get\n\nBREAKING CHANGE: Store.Get signature changed",
+		},
+		{
+			name:    "with body",
+			message: "refactor: simplify store\n\nThe store no longer deletes entries.",
+			want:    "refactor!: simplify store\n\nThe store no longer deletes entries.\n\nBREAKING CHANGE: Store.Get signature changed",
+		},
+		{
+			name:    "existing footer",
+			message: "feat!: new API\n\nBREAKING CHANGE: everything changed",
+			want:    "feat!: new API\n\nBREAKING CHANGE: everything changed",
+		},
+		{
+			name:    "not conventional",
+			message: "Change store API",
+			want:    "Change store API\n\nBREAKING CHANGE: Store.Get signature changed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkBreaking(tt.message, "Store.Get signature changed"); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -3,23 +3,51 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/algernon-coop/git-auto-commit/internal/privacy"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Provider string             `yaml:"provider"`
-	OpenAI   *OpenAIConfig      `yaml:"openai,omitempty"`
-	Azure    *AzureOpenAIConfig `yaml:"azure,omitempty"`
-	Claude   *ClaudeConfig      `yaml:"claude,omitempty"`
-	GitHub   *GitHubConfig      `yaml:"github,omitempty"`
+	Provider   string             `yaml:"provider"`
+	Generation *GenerationConfig  `yaml:"generation,omitempty"`
+	OpenAI     *OpenAIConfig      `yaml:"openai,omitempty"`
+	Azure      *AzureOpenAIConfig `yaml:"azure,omitempty"`
+	Claude     *ClaudeConfig      `yaml:"claude,omitempty"`
+	GitHub     *GitHubConfig      `yaml:"github,omitempty"`
+	Replay     *ReplayConfig      `yaml:"replay,omitempty"`
+	Redaction  *RedactionConfig   `yaml:"redaction,omitempty"`
+	Checks     *ChecksConfig      `yaml:"checks,omitempty"`
+	Diff       *DiffConfig        `yaml:"diff,omitempty"`
+	Audit      *AuditConfig       `yaml:"audit,omitempty"`
+	// Encryption is set when the secrets in the file are encrypted
+	Encryption *EncryptionConfig `yaml:"encryption,omitempty"`
+	// Privacy is "full" (default) to send the redacted diff or "metadata" to send no code
+	Privacy string `yaml:"privacy,omitempty"`
+	// Policy holds the organization policy restrictions for the current repository, if any
+	Policy *Restrictions `yaml:"-"`
 }
 
 // OpenAIConfig represents OpenAI configuration
 type OpenAIConfig struct {
-	APIKey string `yaml:"api_key"`
-	Model  string `yaml:"model"`
+	APIKey           string            `yaml:"api_key"`
+	Model            string            `yaml:"model"`
+	BaseURL          string            `yaml:"base_url,omitempty"`
+	Generation       *GenerationConfig `yaml:"generation,omitempty"`
+	OpenAIAPIOptions `yaml:",inline"`
+}
+
+// OpenAIAPIOptions selects and tunes the OpenAI API used by the OpenAI and Azure OpenAI providers
+type OpenAIAPIOptions struct {
+	// API is chat_completions (default) or responses
+	API string `yaml:"api,omitempty"`
+	// ReasoningEffort is passed to reasoning models: minimal, low, medium or high
+	ReasoningEffort string `yaml:"reasoning_effort,omitempty"`
+	// StructuredOutput requests the message as a JSON object from the Responses API
+	StructuredOutput bool `yaml:"structured_output,omitempty"`
 }
 
 // AzureOpenAIConfig represents Azure OpenAI configuration
@@ -27,28 +55,294 @@ type AzureOpenAIConfig struct {
 	Endpoint   string `yaml:"endpoint"`
 	APIKey     string `yaml:"api_key"`
 	Deployment string `yaml:"deployment"`
+	// Model is the model behind the deployment, used to detect its capabilities; defaults to the deployment name
+	Model            string            `yaml:"model,omitempty"`
+	APIVersion       string            `yaml:"api_version,omitempty"`
+	Auth             *AzureAuthConfig  `yaml:"auth,omitempty"`
+	Generation       *GenerationConfig `yaml:"generation,omitempty"`
+	OpenAIAPIOptions `yaml:",inline"`
+}
+
+// AzureAuthConfig represents Microsoft Entra ID authentication for Azure OpenAI
+type AzureAuthConfig struct {
+	// Method is one of api_key (default), client_credentials, workload_identity or command
+	Method        string `yaml:"method"`
+	TenantID      string `yaml:"tenant_id,omitempty"`
+	ClientID      string `yaml:"client_id,omitempty"`
+	ClientSecret  string `yaml:"client_secret,omitempty"`
+	TokenFile     string `yaml:"token_file,omitempty"`
+	Command       string `yaml:"command,omitempty"`
+	AuthorityHost string `yaml:"authority_host,omitempty"`
+}
+
+// AuthMethod returns the configured authentication method, defaulting to api_key
+func (c *AzureOpenAIConfig) AuthMethod() string {
+	if c.Auth == nil || c.Auth.Method == "" {
+		return "api_key"
+	}
+	return c.Auth.Method
 }
 
 // ClaudeConfig represents Anthropic Claude configuration
 type ClaudeConfig struct {
-	APIKey string `yaml:"api_key"`
-	Model  string `yaml:"model"`
+	APIKey     string            `yaml:"api_key"`
+	Model      string            `yaml:"model"`
+	BaseURL    string            `yaml:"base_url,omitempty"`
+	Generation *GenerationConfig `yaml:"generation,omitempty"`
+	// ThinkingBudget enables extended thinking with the given token budget
+	ThinkingBudget int `yaml:"thinking_budget,omitempty"`
 }
 
 // GitHubConfig represents GitHub Models configuration
 type GitHubConfig struct {
-	Token string `yaml:"token"`
-	Model string `yaml:"model"`
+	Token      string            `yaml:"token,omitempty"`
+	Model      string            `yaml:"model"`
+	BaseURL    string            `yaml:"base_url,omitempty"`
+	Generation *GenerationConfig `yaml:"generation,omitempty"`
+}
+
+// GenerationConfig represents sampling parameters for commit message generation.
+// Unset fields are left to the provider's defaults.
+type GenerationConfig struct {
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top_p,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+	Seed        *int64   `yaml:"seed,omitempty"`
+	Stop        []string `yaml:"stop,omitempty"`
+}
+
+// merge returns g with every field that is set in override replaced
+func (g GenerationConfig) merge(override *GenerationConfig) GenerationConfig {
+	if override == nil {
+		return g
+	}
+	if override.Temperature != nil {
+		g.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		g.TopP = override.TopP
+	}
+	if override.MaxTokens != 0 {
+		g.MaxTokens = override.MaxTokens
+	}
+	if override.Seed != nil {
+		g.Seed = override.Seed
+	}
+	if override.Stop != nil {
+		g.Stop = override.Stop
+	}
+	return g
+}
+
+// WithMaxTokens returns a copy of g with the output token budget replaced
+func (g GenerationConfig) WithMaxTokens(maxTokens int) GenerationConfig {
+	g.MaxTokens = maxTokens
+	return g
+}
+
+// GenerationFor returns the generation parameters for a provider: the global
+// defaults overridden by the provider's own generation block
+func (c *Config) GenerationFor(provider string) GenerationConfig {
+	params := GenerationConfig{}.merge(c.Generation)
+
+	switch provider {
+	case "openai":
+		if c.OpenAI != nil {
+			params = params.merge(c.OpenAI.Generation)
+		}
+	case "azure":
+		if c.Azure != nil {
+			params = params.merge(c.Azure.Generation)
+		}
+	case "claude":
+		if c.Claude != nil {
+			params = params.merge(c.Claude.Generation)
+		}
+	case "github":
+		if c.GitHub != nil {
+			params = params.merge(c.GitHub.Generation)
+		}
+	}
+
+	return params
+}
+
+// RedactionConfig controls how secrets are removed from diffs before they are sent to a provider
+type RedactionConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Patterns are additional regular expressions whose matches are redacted
+	Patterns []string `yaml:"patterns,omitempty"`
+	// DenyPaths are additional glob patterns for files whose contents are never sent
+	DenyPaths []string `yaml:"deny_paths,omitempty"`
+	// EntropyThreshold overrides the bits per character above which a token counts as a secret; negative disables the check
+	EntropyThreshold float64 `yaml:"entropy_threshold,omitempty"`
+}
+
+// ChecksConfig controls the safety checks run on staged content before committing
+type ChecksConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+	// MaxFileSize is the largest staged file in bytes; zero uses the default and negative disables the check
+	MaxFileSize int64 `yaml:"max_file_size,omitempty"`
+	// BinaryPaths are glob patterns for files allowed to be binary, in addition to the defaults
+	BinaryPaths []string `yaml:"binary_paths,omitempty"`
+	// BlockMessage blocks the commit when the generated message fails the output checks,
+	// which otherwise only warn
+	BlockMessage bool `yaml:"block_message,omitempty"`
+}
+
+// DiffConfig controls which staged changes are shown to the provider
+type DiffConfig struct {
+	// Exclude are glob patterns for files summarised in one line instead of shown in full, in addition to the defaults
+	Exclude []string `yaml:"exclude,omitempty"`
+	// MechanicalMinFiles is the number of files a repeated mechanical change must span to be
+	// summarised instead of shown; zero uses the default and negative disables the summaries
+	MechanicalMinFiles int `yaml:"mechanical_min_files,omitempty"`
+}
+
+// AuditConfig controls the audit log of provider calls
+type AuditConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path is the JSON-lines log file; defaults to ~/.git-auto-commit-audit.jsonl
+	Path string `yaml:"path,omitempty"`
+	// IncludePrompt records the full redacted prompt in each entry
+	IncludePrompt bool `yaml:"include_prompt,omitempty"`
+	// MaxSize is the size in bytes at which the log is rotated; zero uses the default
+	MaxSize int64 `yaml:"max_size,omitempty"`
+	// MaxBackups is the number of rotated logs kept; zero uses the default
+	MaxBackups int `yaml:"max_backups,omitempty"`
+}
+
+// ReplayConfig represents the replay provider configuration
+type ReplayConfig struct {
+	Cassette string `yaml:"cassette"`
+}
+
+// ConfiguredProviders returns the names of all providers that have a configuration block
+func (c *Config) ConfiguredProviders() []string {
+	var providers []string
+	if c.OpenAI != nil {
+		providers = append(providers, "openai")
+	}
+	if c.Azure != nil {
+		providers = append(providers, "azure")
+	}
+	if c.Claude != nil {
+		providers = append(providers, "claude")
+	}
+	if c.GitHub != nil {
+		providers = append(providers, "github")
+	}
+	return providers
+}
+
+// Validate checks that the block for the selected provider contains every required field
+func (c *Config) Validate() error {
+	var missing []string
+	switch c.Provider {
+	case "":
+		return fmt.Errorf("no provider selected")
+	case "openai":
+		if c.OpenAI == nil {
+			return fmt.Errorf("openai configuration block is missing")
+		}
+		missing = missingFields(map[string]string{"api_key": c.OpenAI.APIKey, "model": c.OpenAI.Model})
+		if err := c.OpenAI.OpenAIAPIOptions.validate(); err != nil {
+			return err
+		}
+	case "azure":
+		if c.Azure == nil {
+			return fmt.Errorf("azure configuration block is missing")
+		}
+		fields := map[string]string{"endpoint": c.Azure.Endpoint, "deployment": c.Azure.Deployment}
+		switch c.Azure.AuthMethod() {
+		case "api_key":
+			fields["api_key"] = c.Azure.APIKey
+		case "client_credentials":
+			fields["auth.tenant_id"] = c.Azure.Auth.TenantID
+			fields["auth.client_id"] = c.Azure.Auth.ClientID
+			fields["auth.client_secret"] = c.Azure.Auth.ClientSecret
+		case "command":
+			fields["auth.command"] = c.Azure.Auth.Command
+		case "workload_identity":
+			// Tenant, client and token file fall back to the AZURE_* environment variables
+		default:
+			return fmt.Errorf("unknown azure auth method: %s", c.Azure.AuthMethod())
+		}
+		missing = missingFields(fields)
+		if err := c.Azure.OpenAIAPIOptions.validate(); err != nil {
+			return err
+		}
+	case "claude":
+		if c.Claude == nil {
+			return fmt.Errorf("claude configuration block is missing")
+		}
+		missing = missingFields(map[string]string{"api_key": c.Claude.APIKey, "model": c.Claude.Model})
+	case "github":
+		if c.GitHub == nil {
+			return fmt.Errorf("github configuration block is missing")
+		}
+		// The token may also come from GITHUB_TOKEN, GH_TOKEN or the GitHub CLI
+		missing = missingFields(map[string]string{"model": c.GitHub.Model})
+	case "replay":
+		if c.Replay == nil {
+			return fmt.Errorf("replay configuration block is missing")
+		}
+		missing = missingFields(map[string]string{"cassette": c.Replay.Cassette})
+	case "heuristic":
+		// The heuristic provider has no settings
+	default:
+		return fmt.Errorf("unknown provider: %s", c.Provider)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s configuration is missing: %s", c.Provider, strings.Join(missing, ", "))
+	}
+
+	return privacy.Validate(c.Privacy)
+}
+
+// validate checks the API selection
+func (o OpenAIAPIOptions) validate() error {
+	switch o.API {
+	case "", "chat_completions", "responses":
+	default:
+		return fmt.Errorf("unknown api: %s (expected chat_completions or responses)", o.API)
+	}
+	if o.StructuredOutput && o.API != "responses" {
+		return fmt.Errorf("structured_output requires api: responses")
+	}
+	return nil
+}
+
+// missingFields returns the sorted names of the empty fields
+func missingFields(fields map[string]string) []string {
+	var missing []string
+	for name, value := range fields {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// DefaultPath returns the default configuration file location
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return home + "/.git-auto-commit.yaml", nil
 }
 
 // Load loads the configuration from a file
 func Load(path string) (*Config, error) {
 	if path == "" {
-		home, err := os.UserHomeDir()
+		defaultPath, err := DefaultPath()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			return nil, err
 		}
-		path = home + "/.git-auto-commit.yaml"
+		path = defaultPath
 	}
 
 	data, err := os.ReadFile(path)
@@ -61,16 +355,35 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if err := cfg.decryptSecrets(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
-// Save saves the configuration to a file
+// Save saves the configuration to a file, encrypting the secrets if the configuration has
+// encryption settings
 func Save(cfg *Config, path string) error {
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	if cfg.Encryption != nil {
+		// Encrypt a copy so that the caller keeps the plaintext secrets
+		var encrypted Config
+		if err := yaml.Unmarshal(data, &encrypted); err != nil {
+			return fmt.Errorf("failed to copy config: %w", err)
+		}
+		if err := encrypted.encryptSecrets(cfg.Encryption); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(&encrypted); err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+	}
+
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
@@ -159,3 +159,119 @@ func TestConfigFilePermissions(t *testing.T) {
 		t.Errorf("File permissions mismatch: got %o, want %o", mode, expected)
 	}
 }
+
+func TestConfiguredProviders(t *testing.T) {
+	cfg := &Config{
+		Provider: "claude",
+		OpenAI:   &OpenAIConfig{APIKey: "test-key"},
+		Claude:   &ClaudeConfig{APIKey: "test-key"},
+	}
+
+	providers := cfg.ConfiguredProviders()
+	if len(providers) != 2 || providers[0] != "openai" || providers[1] != "claude" {
+		t.Errorf("Unexpected providers: %v", providers)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    *Config
+		expectErr string
+	}{
+		{
+			name:   "Complete",
+			config: &Config{Provider: "openai", OpenAI: &OpenAIConfig{APIKey: "test-key", Model: "gpt-4"}},
+		},
+		{
+			name:   "Heuristic without settings",
+			config: &Config{Provider: "heuristic"},
+		},
+		{
+			name:   "Metadata privacy",
+			config: &Config{Provider: "heuristic", Privacy: "metadata"},
+		},
+		{
+			name:      "Unknown privacy mode",
+			config:    &Config{Provider: "heuristic", Privacy: "none"},
+			expectErr: "unknown privacy mode: none (expected full or metadata)",
+		},
+		{
+			name:      "No provider",
+			config:    &Config{},
+			expectErr: "no provider selected",
+		},
+		{
+			name:      "Missing block",
+			config:    &Config{Provider: "claude"},
+			expectErr: "claude configuration block is missing",
+		},
+		{
+			name:      "Missing fields",
+			config:    &Config{Provider: "azure", Azure: &AzureOpenAIConfig{Endpoint: "https://example.openai.azure.com"}},
+			expectErr: "azure configuration is missing: api_key, deployment",
+		},
+		{
+			name: "Structured output with chat completions",
+			config: &Config{Provider: "openai", OpenAI: &OpenAIConfig{
+				APIKey:           "test-key",
+				Model:            "gpt-4o",
+				OpenAIAPIOptions: OpenAIAPIOptions{StructuredOutput: true},
+			}},
+			expectErr: "structured_output requires api: responses",
+		},
+		{
+			name: "Entra ID without API key",
+			config: &Config{Provider: "azure", Azure: &AzureOpenAIConfig{
+				Endpoint:   "https://example.openai.azure.com",
+				Deployment: "gpt-4o",
+				Auth:       &AzureAuthConfig{Method: "command"},
+			}},
+			expectErr: "azure configuration is missing: auth.command",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectErr {
+				t.Errorf("Expected error %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerationFor(t *testing.T) {
+	zero, half := 0.0, 0.5
+	seed := int64(42)
+
+	cfg := &Config{
+		Provider:   "claude",
+		Generation: &GenerationConfig{Temperature: &zero, Seed: &seed, MaxTokens: 500},
+		Claude: &ClaudeConfig{
+			APIKey:     "test-key",
+			Generation: &GenerationConfig{Temperature: &half, Stop: []string{"\n\n\n"}},
+		},
+	}
+
+	params := cfg.GenerationFor("claude")
+	if params.Temperature == nil || *params.Temperature != 0.5 {
+		t.Errorf("Expected provider temperature to override the global default, got %v", params.Temperature)
+	}
+	if params.Seed == nil || *params.Seed != 42 || params.MaxTokens != 500 {
+		t.Errorf("Expected global seed and max tokens to apply, got %+v", params)
+	}
+	if len(params.Stop) != 1 {
+		t.Errorf("Expected provider stop sequences, got %v", params.Stop)
+	}
+
+	if params := cfg.GenerationFor("openai"); params.Temperature == nil || *params.Temperature != 0 {
+		t.Errorf("Expected global temperature for unconfigured provider, got %v", params.Temperature)
+	}
+}
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// PassphraseEnv is the environment variable holding the passphrase of an encrypted configuration
+	PassphraseEnv = "GIT_AUTO_COMMIT_PASSPHRASE"
+	// IdentityEnv is the environment variable naming the key file of an encrypted configuration
+	IdentityEnv = "GIT_AUTO_COMMIT_IDENTITY"
+)
+
+// Encryption methods
+const (
+	// EncryptPassphrase derives the key from a passphrase
+	EncryptPassphrase = "passphrase"
+	// EncryptKey encrypts for the X25519 public key of a key file, like age recipients
+	EncryptKey = "key"
+)
+
+const (
+	// encryptedPrefix marks an encrypted secret value
+	encryptedPrefix = "enc:v1:"
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations = 600000
+	hkdfInfo         = "git-auto-commit secret"
+)
+
+// PassphrasePrompt asks for the passphrase of an encrypted configuration when
+// GIT_AUTO_COMMIT_PASSPHRASE is not set; nil means the passphrase must come from the environment
+var PassphrasePrompt func() (string, error)
+
+// EncryptionConfig records how the secrets in the configuration file are encrypted
+type EncryptionConfig struct {
+	// Method is passphrase or key
+	Method string `yaml:"method"`
+	// Salt is the base64 salt the passphrase key is derived with
+	Salt string `yaml:"salt,omitempty"`
+	// Recipient is the base64 X25519 public key secrets are encrypted for
+	Recipient string `yaml:"recipient,omitempty"`
+	// Identity is the key file holding the private key; defaults to ~/.git-auto-commit.key
+	Identity string `yaml:"identity,omitempty"`
+
+	// key is the derived passphrase key, kept to encrypt the secrets again on save
+	key []byte
+	// identity is the private key read from the key file
+	identity *ecdh.PrivateKey
+}
+
+// DefaultIdentityPath returns the default key file location
+func DefaultIdentityPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return home + "/.git-auto-commit.key", nil
+}
+
+// GenerateIdentity creates a key file with a new X25519 private key, refusing to overwrite an
+// existing file
+func GenerateIdentity(path string) error {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	content := fmt.Sprintf("# created: %s\n# recipient: %s\n%s\n", time.Now().Format(time.RFC3339),
+		base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), base64.StdEncoding.EncodeToString(key.Bytes()))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// readIdentity reads the private key from a key file, skipping comment lines
+func readIdentity(path string) (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+		}
+		key, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("invalid key file %s: no key found", path)
+}
+
+// EncryptWithPassphrase makes Save encrypt the secrets with a key derived from passphrase
+func (c *Config) EncryptWithPassphrase(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("the passphrase must not be empty")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	c.Encryption = &EncryptionConfig{Method: EncryptPassphrase, Salt: base64.StdEncoding.EncodeToString(salt), key: key}
+	return nil
+}
+
+// EncryptForIdentity makes Save encrypt the secrets for the public key of the key file at path
+func (c *Config) EncryptForIdentity(path string) error {
+	identity, err := readIdentity(path)
+	if err != nil {
+		return err
+	}
+	c.Encryption = &EncryptionConfig{
+		Method:    EncryptKey,
+		Recipient: base64.StdEncoding.EncodeToString(identity.PublicKey().Bytes()),
+		Identity:  path,
+		identity:  identity,
+	}
+	return nil
+}
+
+// secrets returns the credential fields of the configuration that are set
+func (c *Config) secrets() []*string {
+	var fields []*string
+	if c.OpenAI != nil {
+		fields = append(fields, &c.OpenAI.APIKey)
+	}
+	if c.Azure != nil {
+		fields = append(fields, &c.Azure.APIKey)
+		if c.Azure.Auth != nil {
+			fields = append(fields, &c.Azure.Auth.ClientSecret)
+		}
+	}
+	if c.Claude != nil {
+		fields = append(fields, &c.Claude.APIKey)
+	}
+	if c.GitHub != nil {
+		fields = append(fields, &c.GitHub.Token)
+	}
+
+	set := fields[:0]
+	for _, f := range fields {
+		if *f != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+// HasPlaintextSecrets reports whether the configuration would store credentials unencrypted
+func (c *Config) HasPlaintextSecrets() bool {
+	return c.Encryption == nil && len(c.secrets()) > 0
+}
+
+// decryptSecrets replaces encrypted secret values with their plaintext. Values that are not
+// encrypted are kept, so a key added by hand is encrypted on the next save.
+func (c *Config) decryptSecrets() error {
+	for _, field := range c.secrets() {
+		if !strings.HasPrefix(*field, encryptedPrefix) {
+			continue
+		}
+		if c.Encryption == nil {
+			return fmt.Errorf("the config file contains encrypted secrets but no encryption settings")
+		}
+		plaintext, err := c.Encryption.open(*field)
+		if err != nil {
+			return err
+		}
+		*field = plaintext
+	}
+	return nil
+}
+
+// encryptSecrets replaces every secret value with its encrypted form
+func (c *Config) encryptSecrets(e *EncryptionConfig) error {
+	for _, field := range c.secrets() {
+		if strings.HasPrefix(*field, encryptedPrefix) {
+			continue
+		}
+		sealed, err := e.seal(*field)
+		if err != nil {
+			return err
+		}
+		*field = sealed
+	}
+	return nil
+}
+
+// seal encrypts a secret value. With a key file, each value gets its own ephemeral X25519 key,
+// stored ahead of the ciphertext.
+func (e *EncryptionConfig) seal(plaintext string) (string, error) {
+	var key, header []byte
+	switch e.Method {
+	case EncryptPassphrase:
+		var err error
+		if key, err = e.passphraseKey(); err != nil {
+			return "", err
+		}
+	case EncryptKey:
+		raw, err := base64.StdEncoding.DecodeString(e.Recipient)
+		if err != nil {
+			return "", fmt.Errorf("invalid encryption recipient: %w", err)
+		}
+		recipient, err := ecdh.X25519().NewPublicKey(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid encryption recipient: %w", err)
+		}
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate key: %w", err)
+		}
+		header = ephemeral.PublicKey().Bytes()
+		if key, err = sharedKey(ephemeral, recipient, header, raw); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unknown encryption method: %s (expected %s or %s)", e.Method, EncryptPassphrase, EncryptKey)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data := append(append(header, nonce...), gcm.Seal(nil, nonce, []byte(plaintext), nil)...)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// open decrypts a value produced by seal
+func (e *EncryptionConfig) open(value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+
+	var key []byte
+	switch e.Method {
+	case EncryptPassphrase:
+		if key, err = e.passphraseKey(); err != nil {
+			return "", err
+		}
+	case EncryptKey:
+		identity, err := e.loadIdentity()
+		if err != nil {
+			return "", err
+		}
+		if len(data) < 32 {
+			return "", fmt.Errorf("invalid encrypted secret: too short")
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(data[:32])
+		if err != nil {
+			return "", fmt.Errorf("invalid encrypted secret: %w", err)
+		}
+		if key, err = sharedKey(identity, ephemeral, data[:32], identity.PublicKey().Bytes()); err != nil {
+			return "", err
+		}
+		data = data[32:]
+	default:
+		return "", fmt.Errorf("unknown encryption method: %s (expected %s or %s)", e.Method, EncryptPassphrase, EncryptKey)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted secret: too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		if e.Method == EncryptPassphrase {
+			return "", fmt.Errorf("failed to decrypt the config file secrets: wrong passphrase")
+		}
+		return "", fmt.Errorf("failed to decrypt the config file secrets: the key file does not match")
+	}
+	return string(plaintext), nil
+}
+
+// passphraseKey derives the key from the passphrase in the environment or from the prompt,
+// once per configuration
+func (e *EncryptionConfig) passphraseKey() ([]byte, error) {
+	if e.key != nil {
+		return e.key, nil
+	}
+
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" && PassphrasePrompt != nil {
+		var err error
+		if passphrase, err = PassphrasePrompt(); err != nil {
+			return nil, err
+		}
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("the config file secrets are encrypted with a passphrase: set %s", PassphraseEnv)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid encryption salt in the config file")
+	}
+	if e.key, err = deriveKey(passphrase, salt); err != nil {
+		return nil, err
+	}
+	return e.key, nil
+}
+
+// loadIdentity reads the private key from GIT_AUTO_COMMIT_IDENTITY, the configured key file
+// or the default one, and checks that it matches the recipient
+func (e *EncryptionConfig) loadIdentity() (*ecdh.PrivateKey, error) {
+	if e.identity != nil {
+		return e.identity, nil
+	}
+
+	path := os.Getenv(IdentityEnv)
+	if path == "" {
+		path = e.Identity
+	}
+	if path == "" {
+		var err error
+		if path, err = DefaultIdentityPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	identity, err := readIdentity(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("the config file secrets are encrypted for a key file, but %s does not exist: set %s", path, IdentityEnv)
+		}
+		return nil, err
+	}
+	if recipient, err := base64.StdEncoding.DecodeString(e.Recipient); err == nil && !bytes.Equal(recipient, identity.PublicKey().Bytes()) {
+		return nil, fmt.Errorf("the key file %s does not match the recipient the config file secrets are encrypted for", path)
+	}
+	e.identity = identity
+	return identity, nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// sharedKey derives the AES key for one value from an X25519 exchange, binding it to both public keys
+func sharedKey(private *ecdh.PrivateKey, public *ecdh.PublicKey, ephemeral, recipient []byte) ([]byte, error) {
+	shared, err := private.ECDH(public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	key, err := hkdf.Key(sha256.New, shared, salt, hkdfInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func secretConfig() *Config {
+	return &Config{
+		Provider: "azure",
+		OpenAI:   &OpenAIConfig{APIKey: "sk-openai", Model: "gpt-4o"},
+		Azure: &AzureOpenAIConfig{
+			Endpoint:   "https://example.openai.azure.com",
+			APIKey:     "azure-key",
+			Deployment: "gpt-4o",
+			Auth:       &AzureAuthConfig{Method: "client_credentials", ClientSecret: "client-secret"},
+		},
+		Claude: &ClaudeConfig{APIKey: "sk-ant", Model: "claude-sonnet-4-5"},
+		GitHub: &GitHubConfig{Token: "ghp_token", Model: "gpt-4o"},
+	}
+}
+
+// checkEncrypted fails if the saved file contains a plaintext secret
+func checkEncrypted(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	for _, secret := range []string{"sk-openai", "azure-key", "client-secret", "sk-ant", "ghp_token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Config file contains the plaintext secret %q", secret)
+		}
+	}
+	if strings.Count(string(data), encryptedPrefix) != 5 {
+		t.Errorf("Expected 5 encrypted values, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "https://example.openai.azure.com") {
+		t.Error("Settings other than secrets should stay readable")
+	}
+}
+
+// checkSecrets fails if cfg does not hold the secrets of secretConfig
+func checkSecrets(t *testing.T, cfg *Config) {
+	t.Helper()
+	if cfg.OpenAI.APIKey != "sk-openai" || cfg.Azure.APIKey != "azure-key" || cfg.Azure.Auth.ClientSecret != "client-secret" ||
+		cfg.Claude.APIKey != "sk-ant" || cfg.GitHub.Token != "ghp_token" {
+		t.Errorf("Secrets not decrypted: %+v %+v %+v %+v %+v", cfg.OpenAI, cfg.Azure, cfg.Azure.Auth, cfg.Claude, cfg.GitHub)
+	}
+}
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := secretConfig()
+	if err := cfg.EncryptWithPassphrase("correct horse"); err != nil {
+		t.Fatalf("EncryptWithPassphrase failed: %v", err)
+	}
+	if err := Save(cfg, path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	checkEncrypted(t, path)
+	checkSecrets(t, cfg)
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	checkSecrets(t, loaded)
+
+	// Saving a loaded configuration keeps it encrypted
+	loaded.GitHub.Token = "ghp_token"
+	if err := Save(loaded, path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	checkEncrypted(t, path)
+
+	t.Setenv(PassphraseEnv, "wrong horse")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), PassphraseEnv) {
+		t.Errorf("Expected an error naming %s, got %v", PassphraseEnv, err)
+	}
+}
+
+func TestEncryptForIdentity(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	identity := filepath.Join(dir, "key")
+	if err := GenerateIdentity(identity); err != nil {
+		t.Fatalf("GenerateIdentity failed: %v", err)
+	}
+	if err := GenerateIdentity(identity); err == nil {
+		t.Error("Expected GenerateIdentity to refuse to overwrite a key file")
+	}
+
+	cfg := secretConfig()
+	if err := cfg.EncryptForIdentity(identity); err != nil {
+		t.Fatalf("EncryptForIdentity failed: %v", err)
+	}
+	if err := Save(cfg, path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	checkEncrypted(t, path)
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	checkSecrets(t, loaded)
+
+	other := filepath.Join(dir, "other")
+	if err := GenerateIdentity(other); err != nil {
+		t.Fatalf("GenerateIdentity failed: %v", err)
+	}
+	t.Setenv(IdentityEnv, other)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected a key mismatch error, got %v", err)
+	}
+}
+
+func TestLoad_PlaintextSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Save(secretConfig(), path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	checkSecrets(t, loaded)
+	if !loaded.HasPlaintextSecrets() {
+		t.Error("Expected plaintext secrets to be reported")
+	}
+}
+
+func TestLoad_EncryptedWithoutSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "provider: openai\nopenai:\n  api_key: " + encryptedPrefix + "AAAA\n  model: gpt-4o\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "no encryption settings") {
+		t.Errorf("Expected a missing settings error, got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/privacy"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyEnv is the environment variable naming the organization policy file
+const PolicyEnv = "GIT_AUTO_COMMIT_POLICY"
+
+// Policy is an organization policy restricting how matching repositories may be processed.
+// It overrides the user configuration.
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule restricts the repositories matching any of its remotes or paths
+type PolicyRule struct {
+	// Remotes are glob patterns for remote URLs, compared without scheme, user and .git
+	// suffix, such as github.com/acme/*. A pattern also matches the remotes below a path it
+	// matches, so github.com/acme/* covers repositories in subgroups of acme as well.
+	Remotes []string `yaml:"remotes,omitempty"`
+	// Paths are directories containing the repository, or glob patterns for its root
+	Paths []string `yaml:"paths,omitempty"`
+	// AllowedProviders lists the only providers that may be used; empty allows any
+	AllowedProviders []string `yaml:"allowed_providers,omitempty"`
+	// AllowedEndpoints are glob patterns for the hosts prompts may be sent to, such as
+	// *.openai.azure.com; empty allows any
+	AllowedEndpoints []string `yaml:"allowed_endpoints,omitempty"`
+	// Privacy is the minimum privacy mode
+	Privacy          string `yaml:"privacy,omitempty"`
+	RequireRedaction bool   `yaml:"require_redaction,omitempty"`
+	// MaxDiffBytes is the largest prompt that may be sent; zero means no limit
+	MaxDiffBytes int `yaml:"max_diff_bytes,omitempty"`
+	// source is the file the rule was loaded from
+	source string
+}
+
+// Restrictions are the combined policy rules that apply to one repository
+type Restrictions struct {
+	// Source is the path of the policy files whose rules apply, separated by commas
+	Source string
+	// AllowedProviders is nil when any provider is allowed
+	AllowedProviders []string
+	// AllowedEndpoints holds the endpoint patterns of each matching rule that sets them; a
+	// host must match a pattern of every list
+	AllowedEndpoints [][]string
+	Privacy          string
+	RequireRedaction bool
+	MaxDiffBytes     int
+}
+
+// systemPolicyPath is the policy file installed by administrators
+var systemPolicyPath = defaultSystemPolicyPath()
+
+func defaultSystemPolicyPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "git-auto-commit", "policy.yaml")
+	}
+	return "/etc/git-auto-commit/policy.yaml"
+}
+
+// PolicyPaths returns the system policy file, if it exists, followed by the file named by
+// GIT_AUTO_COMMIT_POLICY. Anyone can set the environment variable, so its file only adds
+// restrictions to the system policy and never replaces it.
+func PolicyPaths() []string {
+	var paths []string
+	if _, err := os.Stat(systemPolicyPath); err == nil {
+		paths = append(paths, systemPolicyPath)
+	}
+	if p := os.Getenv(PolicyEnv); p != "" && p != systemPolicyPath {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// LoadPolicies reads and validates policy files and combines their rules, so that the
+// restrictions of every file apply
+func LoadPolicies(paths ...string) (*Policy, error) {
+	combined := &Policy{}
+	for _, p := range paths {
+		policy, err := LoadPolicy(p)
+		if err != nil {
+			return nil, err
+		}
+		combined.Rules = append(combined.Rules, policy.Rules...)
+	}
+	return combined, nil
+}
+
+// LoadPolicy reads and validates a policy file
+func LoadPolicy(p string) (*Policy, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	for i, rule := range policy.Rules {
+		policy.Rules[i].source = p
+		if len(rule.Remotes) == 0 && len(rule.Paths) == 0 {
+			return nil, fmt.Errorf("policy rule %d matches no repository: set remotes or paths", i+1)
+		}
+		if err := privacy.Validate(rule.Privacy); err != nil {
+			return nil, fmt.Errorf("policy rule %d: %w", i+1, err)
+		}
+		patterns := append(append(append([]string{}, rule.Remotes...), rule.Paths...), rule.AllowedEndpoints...)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("policy rule %d: invalid pattern %q: %w", i+1, pattern, err)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// Restrictions combines the rules matching a repository, keeping the strictest value of each
+// setting, or returns nil when no rule matches
+func (p *Policy) Restrictions(root string, remotes []string) *Restrictions {
+	var r *Restrictions
+	for _, rule := range p.Rules {
+		if !rule.matches(root, remotes) {
+			continue
+		}
+		if r == nil {
+			r = &Restrictions{Source: rule.source}
+		} else if !slices.Contains(strings.Split(r.Source, ", "), rule.source) {
+			r.Source += ", " + rule.source
+		}
+
+		if len(rule.AllowedProviders) > 0 {
+			if r.AllowedProviders == nil {
+				r.AllowedProviders = append([]string{}, rule.AllowedProviders...)
+			} else {
+				r.AllowedProviders = intersect(r.AllowedProviders, rule.AllowedProviders)
+			}
+		}
+		if len(rule.AllowedEndpoints) > 0 {
+			r.AllowedEndpoints = append(r.AllowedEndpoints, rule.AllowedEndpoints)
+		}
+		if rule.Privacy == privacy.Metadata {
+			r.Privacy = privacy.Metadata
+		}
+		r.RequireRedaction = r.RequireRedaction || rule.RequireRedaction
+		if rule.MaxDiffBytes > 0 && (r.MaxDiffBytes == 0 || rule.MaxDiffBytes < r.MaxDiffBytes) {
+			r.MaxDiffBytes = rule.MaxDiffBytes
+		}
+	}
+	return r
+}
+
+func (rule PolicyRule) matches(root string, remotes []string) bool {
+	for _, pattern := range rule.Remotes {
+		for _, remote := range remotes {
+			if matchRemote(NormalizeRemote(pattern), NormalizeRemote(remote)) {
+				return true
+			}
+		}
+	}
+
+	root = filepath.ToSlash(filepath.Clean(root))
+	for _, pattern := range rule.Paths {
+		pattern = filepath.ToSlash(filepath.Clean(pattern))
+		if ok, _ := path.Match(pattern, root); ok || root == pattern || strings.HasPrefix(root, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRemote reports whether a normalized remote, or a path above it, matches the pattern.
+// In path.Match a "*" stops at "/", so without the parents github.com/acme/* would not
+// match github.com/acme/team/app.
+func matchRemote(pattern, remote string) bool {
+	for p := remote; ; {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			return false
+		}
+		p = p[:i]
+	}
+}
+
+func intersect(a, b []string) []string {
+	result := []string{}
+	for _, s := range a {
+		for _, t := range b {
+			if s == t {
+				result = append(result, s)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// NormalizeRemote reduces a remote URL to host and path, so that the HTTPS and SSH URLs of a
+// repository compare equal: https://user@github.com/acme/app.git and git@github.com:acme/app
+// both become github.com/acme/app
+func NormalizeRemote(url string) string {
+	rest := url
+	scheme := false
+	if _, after, ok := strings.Cut(url, "://"); ok {
+		rest, scheme = after, true
+	}
+
+	host, p, _ := strings.Cut(rest, "/")
+	if !scheme {
+		// scp-like syntax: [user@]host:path
+		if h, after, ok := strings.Cut(rest, ":"); ok && !strings.Contains(h, "/") {
+			host, p = h, after
+		}
+	}
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+	if h, _, ok := strings.Cut(host, ":"); ok && scheme {
+		host = h
+	}
+
+	p = strings.TrimSuffix(strings.TrimSuffix(strings.Trim(p, "/"), ".git"), "/")
+	if p == "" {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(host) + "/" + p
+}
+
+// AllowsProvider reports whether the restrictions permit a provider
+func (r *Restrictions) AllowsProvider(name string) bool {
+	if r == nil || r.AllowedProviders == nil {
+		return true
+	}
+	for _, allowed := range r.AllowedProviders {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsEndpoint reports whether the restrictions permit sending prompts to a host, given
+// with or without its port
+func (r *Restrictions) AllowsEndpoint(host string) bool {
+	if r == nil {
+		return true
+	}
+	host = strings.ToLower(host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, patterns := range r.AllowedEndpoints {
+		if !slices.ContainsFunc(patterns, func(pattern string) bool {
+			pattern = strings.ToLower(pattern)
+			okHost, _ := path.Match(pattern, host)
+			okName, _ := path.Match(pattern, hostname)
+			return okHost || okName
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyPolicy records the restrictions on the configuration and overrides the settings they
+// constrain: the privacy mode is raised and redaction is re-enabled if required
+func (c *Config) ApplyPolicy(r *Restrictions) {
+	c.Policy = r
+	if r == nil {
+		return
+	}
+	if r.Privacy == privacy.Metadata {
+		c.Privacy = privacy.Metadata
+	}
+	if r.RequireRedaction && c.Redaction != nil {
+		c.Redaction.Disabled = false
+	}
+}
@@ -0,0 +1,375 @@
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+// Update is a dependency added, removed or changed in a manifest
+type Update struct {
+	// Manifest is the path of the manifest declaring the dependency
+	Manifest string
+	Name     string
+	// From is empty for added dependencies and To is empty for removed ones
+	From string
+	To   string
+}
+
+func (u Update) String() string {
+	switch {
+	case u.From == "":
+		return fmt.Sprintf("add %s %s", u.Name, u.To)
+	case u.To == "":
+		return fmt.Sprintf("remove %s %s", u.Name, u.From)
+	}
+	return fmt.Sprintf("bump %s from %s to %s", u.Name, u.From, u.To)
+}
+
+// manifest parses a dependency manifest in a directory into name to version pairs
+type manifest struct {
+	// files are the base names that trigger the manifest when changed
+	files []string
+	parse func(r git.FileReader, rev, dir string) map[string]string
+}
+
+var manifests = []manifest{
+	{[]string{"go.mod"}, parseGoMod},
+	{[]string{"package.json", "package-lock.json"}, parseNPM},
+	{[]string{"Cargo.toml", "Cargo.lock"}, parseCargo},
+}
+
+// Detect compares the dependency manifests touched by the diff between HEAD and the index
+func Detect(r git.FileReader, diff *git.Diff) []Update {
+	var updates []Update
+	seen := map[string]bool{}
+
+	for _, f := range diff.Files {
+		dir, base := path.Dir(f.Path), path.Base(f.Path)
+
+		// Each requirements file is a manifest of its own
+		if strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt") {
+			parse := func(r git.FileReader, rev, _ string) map[string]string {
+				return parseRequirements(read(r, rev, f.Path))
+			}
+			updates = append(updates, compare(f.Path, parse(r, "HEAD", dir), parse(r, "", dir))...)
+			continue
+		}
+
+		for _, m := range manifests {
+			if !contains(m.files, base) || seen[dir+"/"+m.files[0]] {
+				continue
+			}
+			seen[dir+"/"+m.files[0]] = true
+			updates = append(updates, compare(path.Join(dir, m.files[0]), m.parse(r, "HEAD", dir), m.parse(r, "", dir))...)
+		}
+	}
+
+	return updates
+}
+
+func compare(manifest string, oldVersions, newVersions map[string]string) []Update {
+	var updates []Update
+	for name, to := range newVersions {
+		if from := oldVersions[name]; from != to {
+			updates = append(updates, Update{Manifest: manifest, Name: name, From: from, To: to})
+		}
+	}
+	for name, from := range oldVersions {
+		if _, ok := newVersions[name]; !ok {
+			updates = append(updates, Update{Manifest: manifest, Name: name, From: from})
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Name < updates[j].Name })
+	return updates
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// read returns the file contents, or nil if it does not exist at the revision
+func read(r git.FileReader, rev, p string) []byte {
+	src, err := r.ReadFile(rev, p)
+	if err != nil {
+		return nil
+	}
+	return src
+}
+
+// parseGoMod reads the go version and required modules from go.mod
+func parseGoMod(r git.FileReader, rev, dir string) map[string]string {
+	src := read(r, rev, path.Join(dir, "go.mod"))
+	if src == nil {
+		return nil
+	}
+
+	versions := map[string]string{}
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(string(src)))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) >= 2:
+			versions[fields[0]] = strings.TrimPrefix(fields[1], "v")
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) >= 3:
+			versions[fields[1]] = strings.TrimPrefix(fields[2], "v")
+		case (fields[0] == "go" || fields[0] == "toolchain") && len(fields) == 2:
+			versions[fields[0]] = fields[1]
+		}
+	}
+	return versions
+}
+
+// parseNPM reads the dependencies declared in package.json, using the exact versions
+// resolved in package-lock.json where available
+func parseNPM(r git.FileReader, rev, dir string) map[string]string {
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	src := read(r, rev, path.Join(dir, "package.json"))
+	if src == nil || json.Unmarshal(src, &pkg) != nil {
+		return nil
+	}
+
+	versions := map[string]string{}
+	for _, deps := range []map[string]string{pkg.PeerDependencies, pkg.OptionalDependencies, pkg.DevDependencies, pkg.Dependencies} {
+		for name, spec := range deps {
+			versions[name] = spec
+		}
+	}
+
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if src := read(r, rev, path.Join(dir, "package-lock.json")); src != nil && json.Unmarshal(src, &lock) == nil {
+		for name := range versions {
+			if p, ok := lock.Packages["node_modules/"+name]; ok && p.Version != "" {
+				versions[name] = p.Version
+			}
+		}
+	}
+	return versions
+}
+
+// parseCargo reads the dependencies declared in Cargo.toml, using the exact versions
+// resolved in Cargo.lock where available
+func parseCargo(r git.FileReader, rev, dir string) map[string]string {
+	src := read(r, rev, path.Join(dir, "Cargo.toml"))
+	if src == nil {
+		return nil
+	}
+
+	versions := map[string]string{}
+	inDeps := false
+	scanner := bufio.NewScanner(strings.NewReader(string(src)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section := strings.Trim(line, "[]")
+			inDeps = section == "dependencies" || section == "dev-dependencies" || section == "build-dependencies" ||
+				strings.HasSuffix(section, ".dependencies")
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !inDeps || !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		versions[strings.TrimSpace(name)] = cargoVersion(strings.TrimSpace(value))
+	}
+
+	if lock := read(r, rev, path.Join(dir, "Cargo.lock")); lock != nil {
+		resolved := parseCargoLock(lock)
+		for name := range versions {
+			if v, ok := resolved[name]; ok {
+				versions[name] = v
+			}
+		}
+	}
+	return versions
+}
+
+// cargoVersion extracts the version from `"1.0"` or `{ version = "1.0", features = [...] }`
+func cargoVersion(value string) string {
+	if strings.HasPrefix(value, "{") {
+		_, rest, ok := strings.Cut(value, "version")
+		if !ok {
+			return value
+		}
+		_, rest, _ = strings.Cut(rest, `"`)
+		version, _, _ := strings.Cut(rest, `"`)
+		return version
+	}
+	return strings.Trim(value, `"'`)
+}
+
+// parseCargoLock reads the name and version of each [[package]] entry in Cargo.lock
+func parseCargoLock(src []byte) map[string]string {
+	versions := map[string]string{}
+	var name string
+	scanner := bufio.NewScanner(strings.NewReader(string(src)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " = ")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch key {
+		case "name":
+			name = value
+		case "version":
+			versions[name] = value
+		}
+	}
+	return versions
+}
+
+// parseRequirements reads pinned and constrained packages from a pip requirements file
+func parseRequirements(src []byte) map[string]string {
+	if src == nil {
+		return nil
+	}
+
+	versions := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(src)))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		i := strings.IndexAny(line, "=<>~!")
+		if i < 0 {
+			versions[line] = ""
+			continue
+		}
+		name := strings.TrimSpace(line[:i])
+		spec := strings.TrimSpace(line[i:])
+		versions[name] = strings.TrimPrefix(spec, "==")
+	}
+	return versions
+}
+
+// lockfiles are rewritten by the package manager along with the manifest, so their changes
+// follow from the updates read from the manifests
+var lockfiles = []string{"go.sum", "go.work.sum", "package-lock.json", "Cargo.lock"}
+
+// OnlyUpdates reports whether every changed line of the diff declares one of the updates or
+// belongs to a lockfile, so that Message describes the whole change
+func OnlyUpdates(diff *git.Diff, updates []Update) bool {
+	if len(updates) == 0 || len(diff.Files) == 0 {
+		return false
+	}
+
+	declared := map[string]bool{}
+	for _, u := range updates {
+		declared[u.Manifest+"\x00"+u.Name] = true
+	}
+
+	for _, f := range diff.Files {
+		base := path.Base(f.Path)
+		if contains(lockfiles, base) {
+			continue
+		}
+		if f.Binary {
+			return false
+		}
+		for _, line := range append(f.DeletedLines(), f.AddedLines()...) {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			name, ok := declaredName(base, line)
+			if !ok || !declared[f.Path+"\x00"+name] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// declaredName returns the dependency declared by a line of the manifest with the given
+// base name, or false when the line is anything other than a dependency declaration
+func declaredName(base, line string) (string, bool) {
+	switch {
+	case base == "go.mod":
+		code, _, _ := strings.Cut(line, "//")
+		fields := strings.Fields(code)
+		if len(fields) > 0 && fields[0] == "require" {
+			fields = fields[1:]
+		}
+		if len(fields) != 2 || fields[1] == "=>" {
+			return "", false
+		}
+		return fields[0], true
+	case base == "package.json":
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		value = strings.TrimSuffix(strings.TrimSpace(value), ",")
+		if !ok || !strings.HasPrefix(name, `"`) || !strings.HasPrefix(value, `"`) {
+			return "", false
+		}
+		return strings.Trim(name, `"`), true
+	case base == "Cargo.toml":
+		name, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		value = strings.TrimSpace(value)
+		if !ok || strings.HasPrefix(name, "#") || !(strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "{")) {
+			return "", false
+		}
+		return strings.TrimSpace(name), true
+	case strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt"):
+		for name := range parseRequirements([]byte(line)) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Summary lists the dependency updates for inclusion in the prompt
+func Summary(updates []Update) string {
+	if len(updates) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Dependency updates:")
+	for _, u := range updates {
+		fmt.Fprintf(&b, "\n- %s (%s)", u, u.Manifest)
+	}
+	return b.String()
+}
+
+// Message builds a commit message for a change that only updates dependencies
+func Message(updates []Update) string {
+	if len(updates) == 1 {
+		return "chore(deps): " + updates[0].String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "chore(deps): update %d dependencies\n", len(updates))
+	for _, u := range updates {
+		fmt.Fprintf(&b, "\n- %s", u)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package deps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/git/gittest"
+)
+
+func changed(paths ...string) *git.Diff {
+	diff := &git.Diff{}
+	for _, p := range paths {
+		diff.Files = append(diff.Files, git.FileDiff{Path: p, OldPath: p, Status: git.StatusModified})
+	}
+	return diff
+}
+
+func detected(updates []Update) string {
+	var lines []string
+	for _, u := range updates {
+		lines = append(lines, u.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDetect_GoMod(t *testing.T) {
+	reader := gittest.Files{
+		"HEAD:go.mod": "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgolang.org/x/net v0.21.0\n\tgithub.com/old/dep v1.0.0 // indirect\n)\n\nrequire gopkg.in/yaml.v3 v3.0.1\n",
+		":go.mod":     "module example.com/app\n\ngo 1.23\n\nrequire (\n\tgolang.org/x/net v0.23.0\n\tgithub.com/new/dep v0.2.0\n)\n\nrequire gopkg.in/yaml.v3 v3.0.1\n",
+	}
+
+	expected := "add github.com/new/dep 0.2.0\nremove github.com/old/dep 1.0.0\nbump go from 1.22 to 1.23\nbump golang.org/x/net from 0.21.0 to 0.23.0"
+	if got := detected(Detect(reader, changed("go.mod", "go.sum"))); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDetect_NPMWithLockfile(t *testing.T) {
+	reader := gittest.Files{
+		"HEAD:web/package.json":      `{"dependencies": {"react": "^18.2.0"}, "devDependencies": {"vite": "^5.0.0"}}`,
+		":web/package.json":          `{"dependencies": {"react": "^18.2.0"}, "devDependencies": {"vite": "^5.0.0"}}`,
+		"HEAD:web/package-lock.json": `{"packages": {"node_modules/react": {"version": "18.2.0"}, "node_modules/vite": {"version": "5.0.2"}, "node_modules/esbuild": {"version": "0.19.0"}}}`,
+		":web/package-lock.json":     `{"packages": {"node_modules/react": {"version": "18.3.1"}, "node_modules/vite": {"version": "5.0.2"}, "node_modules/esbuild": {"version": "0.20.0"}}}`,
+	}
+
+	// Transitive packages in the lockfile are not reported
+	expected := "bump react from 18.2.0 to 18.3.1"
+	if got := detected(Detect(reader, changed("web/package-lock.json"))); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestDetect_CargoAndRequirements(t *testing.T) {
+	reader := gittest.Files{
+		"HEAD:Cargo.toml":           "[package]\nname = \"app\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\nanyhow = \"1.0.70\"\n",
+		":Cargo.toml":               "[package]\nname = \"app\"\nversion = \"0.2.0\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\nanyhow = \"1.0.80\"\n",
+		"HEAD:requirements-dev.txt": "pytest==7.4.0\nblack>=23.0  # formatter\n",
+		":requirements-dev.txt":     "pytest==8.0.0\nblack>=23.0  # formatter\nruff==0.3.0\n",
+	}
+
+	expected := "bump anyhow from 1.0.70 to 1.0.80\nbump pytest from 7.4.0 to 8.0.0\nadd ruff 0.3.0"
+	if got := detected(Detect(reader, changed("Cargo.toml", "requirements-dev.txt"))); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	single := []Update{{Manifest: "go.mod", Name: "golang.org/x/net", From: "0.21.0", To: "0.23.0"}}
+	if got := Message(single); got != "chore(deps): bump golang.org/x/net from 0.21.0 to 0.23.0" {
+		t.Errorf("Unexpected message: %q", got)
+	}
+
+	multiple := append(single, Update{Manifest: "go.mod", Name: "github.com/new/dep", To: "0.2.0"})
+	expected := "chore(deps): update 2 dependencies\n\n- bump golang.org/x/net from 0.21.0 to 0.23.0\n- add github.com/new/dep 0.2.0"
+	if got := Message(multiple); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if summary := Summary(single); summary != "Dependency updates:\n- bump golang.org/x/net from 0.21.0 to 0.23.0 (go.mod)" {
+		t.Errorf("Unexpected summary: %q", summary)
+	}
+}
+
+func TestOnlyUpdates(t *testing.T) {
+	goMod := git.FileDiff{Path: "go.mod", Status: git.StatusModified, Hunks: []git.Hunk{{Lines: []string{
+		" require (", "-\tgolang.org/x/net v0.21.0", "+\tgolang.org/x/net v0.23.0", " )",
+	}}}}
+	goSum := git.FileDiff{Path: "go.sum", Status: git.StatusModified, Hunks: []git.Hunk{{Lines: []string{
+		"-golang.org/x/net v0.21.0 h1:abc=", "+golang.org/x/net v0.23.0 h1:def=", "+golang.org/x/text v0.14.0 h1:ghi=",
+	}}}}
+	replace := git.FileDiff{Path: "go.mod", Status: git.StatusModified, Hunks: []git.Hunk{{Lines: []string{
+		"-\tgolang.org/x/net v0.21.0", "+\tgolang.org/x/net v0.23.0", "+replace example.com/lib => ../lib",
+	}}}}
+	cargo := git.FileDiff{Path: "Cargo.toml", Status: git.StatusModified, Hunks: []git.Hunk{{Lines: []string{
+		"-version = \"0.1.0\"", "+version = \"0.2.0\"", "-anyhow = \"1.0.70\"", "+anyhow = \"1.0.80\"",
+	}}}}
+	bump := []Update{{Manifest: "go.mod", Name: "golang.org/x/net", From: "0.21.0", To: "0.23.0"}}
+
+	tests := []struct {
+		name    string
+		files   []git.FileDiff
+		updates []Update
+		want    bool
+	}{
+		{"bump with lockfile", []git.FileDiff{goMod, goSum}, bump, true},
+		{"replace directive", []git.FileDiff{replace}, bump, false},
+		{"package version", []git.FileDiff{cargo}, []Update{{Manifest: "Cargo.toml", Name: "anyhow", From: "1.0.70", To: "1.0.80"}}, false},
+		{"other file", []git.FileDiff{goMod, {Path: "main.go", Status: git.StatusModified, Hunks: []git.Hunk{{Lines: []string{"+// bump"}}}}}, bump, false},
+		{"no updates", []git.FileDiff{goSum}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OnlyUpdates(&git.Diff{Files: tt.files}, tt.updates); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package exclude
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+)
+
+// Attributes are the gitattributes consulted when deciding whether to omit a file
+var Attributes = []string{"linguist-generated", "diff", "auto-commit-ignore"}
+
+// DefaultPatterns match lockfiles, generated code and vendored dependencies
+var DefaultPatterns = []string{
+	"go.sum",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"poetry.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"*.pb.go",
+	"*.min.js",
+	"vendor/",
+	"node_modules/",
+}
+
+// Omitted is a staged file whose hunks are replaced by a one-line summary in the prompt
+type Omitted struct {
+	File   git.FileDiff
+	Reason string
+}
+
+// Select returns the files to omit from the prompt. A file is omitted when it matches
+// one of the patterns or is marked linguist-generated, -diff or auto-commit-ignore in
+// .gitattributes; setting -auto-commit-ignore keeps a file that a pattern would omit.
+func Select(files []git.FileDiff, patterns []string, attrs map[string]map[string]string) []Omitted {
+	var omitted []Omitted
+	for _, f := range files {
+		if reason := omitReason(f.Path, patterns, attrs[f.Path]); reason != "" {
+			omitted = append(omitted, Omitted{File: f, Reason: reason})
+		}
+	}
+	return omitted
+}
+
+func omitReason(path string, patterns []string, attrs map[string]string) string {
+	switch attrs["auto-commit-ignore"] {
+	case git.AttrSet, "true":
+		return "ignored"
+	case git.AttrUnset, "false":
+		return ""
+	}
+
+	switch {
+	case attrs["linguist-generated"] == git.AttrSet || attrs["linguist-generated"] == "true":
+		return "generated"
+	case attrs["diff"] == git.AttrUnset:
+		return "diff disabled"
+	case pathmatch.Match(patterns, path):
+		return "excluded"
+	}
+	return ""
+}
+
+// Paths returns the paths of the omitted files, including the source of renames and copies
+func Paths(omitted []Omitted) []string {
+	var paths []string
+	for _, o := range omitted {
+		paths = append(paths, o.File.Path)
+		if o.File.OldPath != "" && o.File.OldPath != o.File.Path {
+			paths = append(paths, o.File.OldPath)
+		}
+	}
+	return paths
+}
+
+// Summary describes the omitted files, one line each, for inclusion in the prompt
+func Summary(omitted []Omitted) string {
+	if len(omitted) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Files changed but omitted from the diff:")
+	for _, o := range omitted {
+		fmt.Fprintf(&b, "\n- %s (%s, %s)", o.File.Path, o.Reason, changeSummary(o.File))
+	}
+	return b.String()
+}
+
+func changeSummary(f git.FileDiff) string {
+	lines := fmt.Sprintf("+%d -%d lines", f.Added, f.Deleted)
+	if f.Binary {
+		lines = "binary"
+	}
+
+	switch f.Status {
+	case git.StatusAdded:
+		return "added, " + lines
+	case git.StatusDeleted:
+		return "deleted"
+	case git.StatusRenamed:
+		return "renamed from " + f.OldPath + ", " + lines
+	}
+	return lines
+}
@@ -0,0 +1,63 @@
+package exclude
+
+import (
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+func TestSelect(t *testing.T) {
+	files := []git.FileDiff{
+		{Path: "main.go", Status: git.StatusModified, Added: 3, Deleted: 1},
+		{Path: "go.sum", Status: git.StatusModified, Added: 12, Deleted: 4},
+		{Path: "api/service.pb.go", Status: git.StatusAdded, Added: 400},
+		{Path: "docs/schema.json", Status: git.StatusModified, Added: 10, Deleted: 10},
+		{Path: "assets/data.bin", Status: git.StatusModified, Binary: true},
+		{Path: "fixtures/big.txt", Status: git.StatusDeleted},
+		{Path: "package-lock.json", Status: git.StatusModified, Added: 2, Deleted: 2},
+	}
+	attrs := map[string]map[string]string{
+		"docs/schema.json":  {"linguist-generated": "true"},
+		"assets/data.bin":   {"diff": git.AttrUnset},
+		"fixtures/big.txt":  {"auto-commit-ignore": git.AttrSet},
+		"package-lock.json": {"auto-commit-ignore": git.AttrUnset},
+	}
+
+	omitted := Select(files, DefaultPatterns, attrs)
+
+	expected := map[string]string{
+		"go.sum":            "excluded",
+		"api/service.pb.go": "excluded",
+		"docs/schema.json":  "generated",
+		"assets/data.bin":   "diff disabled",
+		"fixtures/big.txt":  "ignored",
+	}
+	if len(omitted) != len(expected) {
+		t.Fatalf("Expected %d omitted files, got %+v", len(expected), omitted)
+	}
+	for _, o := range omitted {
+		if expected[o.File.Path] != o.Reason {
+			t.Errorf("Unexpected reason for %s: %q", o.File.Path, o.Reason)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	summary := Summary([]Omitted{
+		{File: git.FileDiff{Path: "go.sum", Status: git.StatusModified, Added: 12, Deleted: 4}, Reason: "excluded"},
+		{File: git.FileDiff{Path: "api/service.pb.go", Status: git.StatusAdded, Added: 400}, Reason: "excluded"},
+		{File: git.FileDiff{Path: "assets/data.bin", Status: git.StatusModified, Binary: true}, Reason: "diff disabled"},
+	})
+
+	expected := "Files changed but omitted from the diff:\n" +
+		"- go.sum (excluded, +12 -4 lines)\n" +
+		"- api/service.pb.go (excluded, added, +400 -0 lines)\n" +
+		"- assets/data.bin (diff disabled, binary)"
+	if summary != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, summary)
+	}
+
+	if Summary(nil) != "" {
+		t.Error("Expected empty summary for no omitted files")
+	}
+}
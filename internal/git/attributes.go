@@ -0,0 +1,70 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Attribute values reported by git check-attr for attributes without a value
+const (
+	AttrSet         = "set"
+	AttrUnset       = "unset"
+	AttrUnspecified = "unspecified"
+)
+
+// Attributes returns the values of the given gitattributes for each path, read from the index.
+// Paths are relative to the repository root.
+func (r *Repository) Attributes(paths []string, attrs ...string) (map[string]map[string]string, error) {
+	result := map[string]map[string]string{}
+	if len(paths) == 0 || len(attrs) == 0 {
+		return result, nil
+	}
+
+	top, err := r.TopLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"check-attr", "--cached", "-z", "--stdin"}, attrs...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = top
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read git attributes: %w (stderr: %s)", err, stderr.String())
+	}
+
+	// Output is a sequence of "<path>\0<attribute>\0<value>\0" records
+	fields := strings.Split(stdout.String(), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		path, attr, value := fields[i], fields[i+1], fields[i+2]
+		if result[path] == nil {
+			result[path] = map[string]string{}
+		}
+		result[path][attr] = value
+	}
+
+	return result, nil
+}
+
+// TopLevel returns the root directory of the work tree
+func (r *Repository) TopLevel() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to locate repository root: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,328 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileStatus is the kind of change made to a file
+type FileStatus string
+
+// File statuses reported in a diff
+const (
+	StatusAdded    FileStatus = "added"
+	StatusModified FileStatus = "modified"
+	StatusDeleted  FileStatus = "deleted"
+	StatusRenamed  FileStatus = "renamed"
+	StatusCopied   FileStatus = "copied"
+)
+
+// submoduleMode is the file mode git uses for submodule (gitlink) entries
+const submoduleMode = "160000"
+
+// lfsPointerHeader is the first line of a Git LFS pointer file
+const lfsPointerHeader = "version https://git-lfs.github.com/spec/v1"
+
+// Diff is a parsed unified diff
+type Diff struct {
+	// Raw is the diff text as produced by git
+	Raw   string
+	Files []FileDiff
+}
+
+// FileDiff is the change made to a single file
+type FileDiff struct {
+	// Path is the path after the change; OldPath differs from it for renames and copies
+	Path    string
+	OldPath string
+	Status  FileStatus
+	// Similarity is the rename or copy similarity index in percent
+	Similarity int
+	OldMode    string
+	NewMode    string
+	Binary     bool
+	// LFS reports whether the file is a Git LFS pointer
+	LFS bool
+	// Submodule holds the old and new commits when the file is a submodule
+	Submodule *SubmoduleChange
+	Added     int
+	Deleted   int
+	Hunks     []Hunk
+	// Raw is the section of the diff text describing this file
+	Raw string
+}
+
+// SubmoduleChange describes a change of the commit a submodule points to
+type SubmoduleChange struct {
+	OldCommit string
+	NewCommit string
+}
+
+// Hunk is a contiguous block of changed lines
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Header is the function context git prints after the hunk range, if any
+	Header string
+	// Lines holds the hunk lines including their " ", "+" or "-" prefix
+	Lines []string
+}
+
+// ModeChanged reports whether the file mode changed without the file being added or deleted
+func (f *FileDiff) ModeChanged() bool {
+	return f.OldMode != "" && f.NewMode != "" && f.OldMode != f.NewMode
+}
+
+// AddedLines returns the content of the added lines without their prefix
+func (f *FileDiff) AddedLines() []string {
+	return f.changedLines('+')
+}
+
+// DeletedLines returns the content of the deleted lines without their prefix
+func (f *FileDiff) DeletedLines() []string {
+	return f.changedLines('-')
+}
+
+func (f *FileDiff) changedLines(prefix byte) []string {
+	var lines []string
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			if len(line) > 0 && line[0] == prefix {
+				lines = append(lines, line[1:])
+			}
+		}
+	}
+	return lines
+}
+
+// ParseDiff parses the output of git diff. Joining the Raw sections of the files
+// with newlines gives back the original text.
+func ParseDiff(raw string) *Diff {
+	d := &Diff{Raw: raw}
+	if raw == "" {
+		return d
+	}
+	for _, section := range splitSections(raw) {
+		if !strings.HasPrefix(section, "diff --git ") {
+			continue
+		}
+		d.Files = append(d.Files, parseFile(section))
+	}
+	return d
+}
+
+// splitSections splits a diff into per-file sections starting with "diff --git"
+func splitSections(diff string) []string {
+	var sections []string
+	for {
+		i := strings.Index(diff, "\ndiff --git ")
+		if i < 0 {
+			return append(sections, diff)
+		}
+		sections = append(sections, diff[:i])
+		diff = diff[i+1:]
+	}
+}
+
+func parseFile(section string) FileDiff {
+	lines := strings.Split(section, "\n")
+	f := FileDiff{Status: StatusModified, Raw: section}
+	var resolved bool
+	f.OldPath, f.Path, resolved = headerPaths(strings.TrimPrefix(lines[0], "diff --git "))
+
+	var hunk *Hunk
+	oldLeft, newLeft := 0, 0
+	for _, line := range lines[1:] {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0 || strings.HasPrefix(line, `\`)) {
+			hunk.Lines = append(hunk.Lines, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+				f.Added++
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+				f.Deleted++
+			case strings.HasPrefix(line, `\`):
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		if h, ok := parseHunkHeader(line); ok {
+			f.Hunks = append(f.Hunks, h)
+			hunk = &f.Hunks[len(f.Hunks)-1]
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			continue
+		}
+		if hunk != nil {
+			continue
+		}
+
+		parseExtendedHeader(&f, line, resolved)
+	}
+
+	f.detectPointers()
+	return f
+}
+
+// parseExtendedHeader applies one of the header lines between "diff --git" and the first hunk.
+// The ---/+++ lines only set the paths when the "diff --git" line was ambiguous.
+func parseExtendedHeader(f *FileDiff, line string, resolved bool) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		f.Status = StatusAdded
+		f.NewMode = strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.Status = StatusDeleted
+		f.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		f.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		f.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "similarity index "):
+		f.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "rename from "):
+		f.Status = StatusRenamed
+		f.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		f.Path = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		f.Status = StatusCopied
+		f.OldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		f.Path = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "index "):
+		// "index <old>..<new> <mode>" carries the mode when it did not change
+		fields := strings.Fields(line)
+		if len(fields) == 3 && f.OldMode == "" && f.NewMode == "" {
+			f.OldMode, f.NewMode = fields[2], fields[2]
+		}
+	case strings.HasPrefix(line, "--- "):
+		if p := markerPath(strings.TrimPrefix(line, "--- ")); p != "/dev/null" && !resolved {
+			f.OldPath = strings.TrimPrefix(p, "a/")
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if p := markerPath(strings.TrimPrefix(line, "+++ ")); p != "/dev/null" && !resolved {
+			f.Path = strings.TrimPrefix(p, "b/")
+		}
+	case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+		f.Binary = true
+	}
+}
+
+// detectPointers recognises submodule and Git LFS pointer changes
+func (f *FileDiff) detectPointers() {
+	if f.OldMode == submoduleMode || f.NewMode == submoduleMode {
+		f.Submodule = &SubmoduleChange{}
+		for _, line := range f.DeletedLines() {
+			f.Submodule.OldCommit = strings.TrimPrefix(line, "Subproject commit ")
+		}
+		for _, line := range f.AddedLines() {
+			f.Submodule.NewCommit = strings.TrimPrefix(line, "Subproject commit ")
+		}
+		return
+	}
+
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			if len(line) > 0 && line[1:] == lfsPointerHeader {
+				f.LFS = true
+				return
+			}
+		}
+	}
+}
+
+// parseHunkHeader parses a "@@ -a,b +c,d @@ header" line
+func parseHunkHeader(line string) (Hunk, bool) {
+	if !strings.HasPrefix(line, "@@ -") {
+		return Hunk{}, false
+	}
+	rest := strings.TrimPrefix(line, "@@ ")
+	ranges, header, ok := strings.Cut(rest, " @@")
+	if !ok {
+		return Hunk{}, false
+	}
+	oldRange, newRange, ok := strings.Cut(ranges, " ")
+	if !ok {
+		return Hunk{}, false
+	}
+
+	h := Hunk{Header: strings.TrimSpace(header)}
+	h.OldStart, h.OldLines = parseRange(strings.TrimPrefix(oldRange, "-"))
+	h.NewStart, h.NewLines = parseRange(strings.TrimPrefix(newRange, "+"))
+	return h, true
+}
+
+// parseRange parses "start,count"; a missing count means one line
+func parseRange(r string) (int, int) {
+	startText, countText, ok := strings.Cut(r, ",")
+	start, _ := strconv.Atoi(startText)
+	if !ok {
+		return start, 1
+	}
+	count, _ := strconv.Atoi(countText)
+	return start, count
+}
+
+// headerPaths extracts the paths from the "a/<old> b/<new>" part of a diff header.
+// Unquoted paths containing " b/" are ambiguous, so the result is refined by later header lines.
+func headerPaths(header string) (string, string, bool) {
+	if strings.HasPrefix(header, `"`) {
+		if end := closingQuote(header); end > 0 {
+			oldPath := unquotePath(header[:end+1])
+			newPath := unquotePath(strings.TrimSpace(header[end+1:]))
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/"), true
+		}
+	}
+
+	// Without renames both paths are equal: "a/<path> b/<path>"
+	if n := len(header); n >= 5 && (n-5)%2 == 0 {
+		half := (n - 5) / 2
+		oldPath, newPath := header[2:2+half], header[n-half:]
+		if oldPath == newPath {
+			return oldPath, newPath, true
+		}
+	}
+
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return strings.TrimPrefix(header[:i], "a/"), unquotePath(header[i+3:]), false
+	}
+	return header, header, false
+}
+
+// markerPath returns the path of a ---/+++ line. Git ends the line with a tab when the
+// path contains a space, so the tab and anything after it are not part of the path.
+func markerPath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		return unquotePath(p)
+	}
+	p, _, _ = strings.Cut(p, "\t")
+	return p
+}
+
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquotePath decodes a path git quoted because it contains special characters
+func unquotePath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			return unquoted
+		}
+	}
+	return p
+}
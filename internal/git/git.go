@@ -2,10 +2,12 @@ package git
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -19,9 +21,80 @@ func NewRepository(path string) *Repository {
 	return &Repository{path: path}
 }
 
-// GetStagedDiff returns the diff of staged changes
-func (r *Repository) GetStagedDiff() (string, error) {
-	cmd := exec.Command("git", "diff", "--cached")
+// GetStagedDiff returns the parsed diff of staged changes, leaving out the excluded paths
+func (r *Repository) GetStagedDiff(exclude ...string) (*Diff, error) {
+	args := []string{"diff", "--cached"}
+	if len(exclude) > 0 {
+		// Paths are relative to the repository root, as in the diff headers
+		args = append(args, "--", ":/")
+		for _, path := range exclude {
+			args = append(args, ":(top,exclude,literal)"+path)
+		}
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to get staged diff: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return ParseDiff(strings.TrimSpace(stdout.String())), nil
+}
+
+// StagedSizes returns the size in bytes of the staged blob of each path. Deleted files and
+// entries without a blob, such as submodules, are left out.
+func (r *Repository) StagedSizes(paths ...string) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	if len(paths) == 0 {
+		return sizes, nil
+	}
+
+	var query strings.Builder
+	for _, p := range paths {
+		query.WriteString(":" + p + "\n")
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch-check=%(objectsize)")
+	cmd.Dir = r.path
+	cmd.Stdin = strings.NewReader(query.String())
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read staged file sizes: %w (stderr: %s)", err, stderr.String())
+	}
+
+	// Missing entries report "<object> missing" instead of a size
+	for i, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		if i >= len(paths) {
+			break
+		}
+		if size, err := strconv.ParseInt(line, 10, 64); err == nil {
+			sizes[paths[i]] = size
+		}
+	}
+
+	return sizes, nil
+}
+
+// FileReader reads files and lists directories at a revision, or in the index when rev is
+// empty. Repository implements it; gittest.Files is a fake for tests.
+type FileReader interface {
+	ReadFile(rev, path string) ([]byte, error)
+	ListFiles(rev, dir string) ([]string, error)
+}
+
+// ReadFile returns the contents of a file at a revision, or in the index when rev is empty.
+// Paths are relative to the repository root.
+func (r *Repository) ReadFile(rev, path string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", rev+":"+path)
 	cmd.Dir = r.path
 
 	var stdout, stderr bytes.Buffer
@@ -29,12 +102,162 @@ func (r *Repository) GetStagedDiff() (string, error) {
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to get staged diff: %w (stderr: %s)", err, stderr.String())
+		return nil, fmt.Errorf("failed to read %s:%s: %w (stderr: %s)", rev, path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// ListFiles returns the files directly inside a directory at a revision, or in the index
+// when rev is empty. The directory and the returned paths are relative to the repository root.
+func (r *Repository) ListFiles(rev, dir string) ([]string, error) {
+	top, err := r.TopLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if dir != "" && dir != "." {
+		prefix = strings.TrimSuffix(dir, "/") + "/"
+	}
+
+	args := []string{"ls-files", "--full-name", "-z", "--", prefix}
+	if rev != "" {
+		args = []string{"ls-tree", "-r", "--full-name", "--name-only", "-z", rev, prefix}
+	}
+	if prefix == "" {
+		args = args[:len(args)-1]
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = top
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w (stderr: %s)", dir, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []string
+	for _, path := range strings.Split(stdout.String(), "\x00") {
+		// Both commands recurse into subdirectories, whose files are skipped
+		if path == "" || strings.Contains(strings.TrimPrefix(path, prefix), "/") {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// CurrentBranch returns the short name of the checked out branch, or an empty string when HEAD is detached
+func (r *Repository) CurrentBranch() (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get current branch: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
 	}
 
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// ConfigValue returns the value of a git configuration key as seen from the repository,
+// including repository, global and system settings, or an empty string when it is not set
+func (r *Repository) ConfigValue(key string) (string, error) {
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git config %s: %w (stderr: %s)", key, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// RemoteURLs returns the URLs of the configured remotes
+func (r *Repository) RemoteURLs() ([]string, error) {
+	cmd := exec.Command("git", "config", "--get-regexp", `^remote\..*\.url$`)
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list remotes: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		if _, url, ok := strings.Cut(line, " "); ok {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+// CheckWorkTree returns an error unless the repository path is inside a git work tree
+func (r *Repository) CheckWorkTree() error {
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("not inside a git work tree: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if strings.TrimSpace(stdout.String()) != "true" {
+		return fmt.Errorf("not inside a git work tree")
+	}
+
+	return nil
+}
+
+// HooksDir returns the directory git runs hooks from, honouring core.hooksPath
+func (r *Repository) HooksDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w (stderr: %s)", err, stderr.String())
+	}
+
+	dir := strings.TrimSpace(stdout.String())
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.path, dir)
+	}
+	return dir, nil
+}
+
 // Commit commits the staged changes with the given message
 func (r *Repository) Commit(message string) error {
 	cmd := exec.Command("git", "commit", "-m", message)
@@ -50,6 +273,22 @@ func (r *Repository) Commit(message string) error {
 	return nil
 }
 
+// HeadCommit returns the hash of the commit HEAD points to
+func (r *Repository) HeadCommit() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = r.path
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
 // GetCommitGuidelines returns commit message guidelines from the repository
 // It checks for CONTRIBUTING.md, .gitmessage, and Copilot instructions files
 func (r *Repository) GetCommitGuidelines() string {
@@ -67,11 +67,15 @@ func TestGetStagedDiff(t *testing.T) {
 		t.Fatalf("GetStagedDiff failed: %v", err)
 	}
 
-	if diff == "" {
+	if diff.Raw == "" {
 		t.Error("Expected non-empty diff")
 	}
 
-	if !strings.Contains(diff, "modified content") {
+	if len(diff.Files) != 1 || diff.Files[0].Path != "test.txt" {
+		t.Errorf("Expected a single file diff for test.txt, got %+v", diff.Files)
+	}
+
+	if !strings.Contains(diff.Raw, "modified content") {
 		t.Error("Diff should contain 'modified content'")
 	}
 }
@@ -94,8 +98,8 @@ func TestGetStagedDiff_NoChanges(t *testing.T) {
 		t.Fatalf("GetStagedDiff failed: %v", err)
 	}
 
-	if diff != "" {
-		t.Errorf("Expected empty diff, got: %s", diff)
+	if diff.Raw != "" || len(diff.Files) != 0 {
+		t.Errorf("Expected empty diff, got: %s", diff.Raw)
 	}
 }
 
@@ -417,3 +421,229 @@ Other content.
 		t.Error("Result should not contain the next section")
 	}
 }
+
+func TestCheckWorkTreeAndHooksDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	repo := NewRepository(tmpDir)
+	if err := repo.CheckWorkTree(); err == nil {
+		t.Error("Expected error outside a git work tree, got nil")
+	}
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to initialize git repo: %v", err)
+	}
+
+	if err := repo.CheckWorkTree(); err != nil {
+		t.Errorf("CheckWorkTree failed: %v", err)
+	}
+
+	hooksDir, err := repo.HooksDir()
+	if err != nil {
+		t.Fatalf("HooksDir failed: %v", err)
+	}
+	if hooksDir != filepath.Join(tmpDir, ".git", "hooks") {
+		t.Errorf("Unexpected hooks directory: %s", hooksDir)
+	}
+}
+
+func TestStagedSizes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to initialize git repo: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create text file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "logo.png"), []byte{0x89, 'P', 'N', 'G', 0x00, 0x01}, 0644); err != nil {
+		t.Fatalf("Failed to create binary file: %v", err)
+	}
+
+	cmd = exec.Command("git", "add", ".")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to stage files: %v", err)
+	}
+
+	repo := NewRepository(tmpDir)
+	sizes, err := repo.StagedSizes("logo.png", "main.go", "deleted.txt")
+	if err != nil {
+		t.Fatalf("StagedSizes failed: %v", err)
+	}
+
+	if len(sizes) != 2 || sizes["logo.png"] != 6 || sizes["main.go"] != 13 {
+		t.Errorf("Unexpected sizes: %v", sizes)
+	}
+}
+
+func TestGetStagedDiff_ExcludeAndAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to initialize git repo: %v", err)
+	}
+
+	files := map[string]string{
+		"main.go":        "package main\n",
+		"go.sum":         "example.com/mod v1.0.0 h1:abc=\n",
+		"gen/types.go":   "package gen\n",
+		".gitattributes": "gen/** linguist-generated\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	cmd = exec.Command("git", "add", ".")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to stage files: %v", err)
+	}
+
+	repo := NewRepository(tmpDir)
+	diff, err := repo.GetStagedDiff("go.sum", "gen/types.go")
+	if err != nil {
+		t.Fatalf("GetStagedDiff failed: %v", err)
+	}
+	if len(diff.Files) != 2 || diff.Files[0].Path != ".gitattributes" || diff.Files[1].Path != "main.go" {
+		t.Errorf("Unexpected diff with exclusions:\n%s", diff.Raw)
+	}
+
+	attrs, err := repo.Attributes([]string{"main.go", "gen/types.go"}, "linguist-generated")
+	if err != nil {
+		t.Fatalf("Attributes failed: %v", err)
+	}
+	if attrs["gen/types.go"]["linguist-generated"] != AttrSet {
+		t.Errorf("Expected gen/types.go to be generated, got %v", attrs["gen/types.go"])
+	}
+	if attrs["main.go"]["linguist-generated"] != AttrUnspecified {
+		t.Errorf("Expected main.go to be unspecified, got %v", attrs["main.go"])
+	}
+}
+
+func TestReadFileAndListFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.name", "Test User"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "pkg", "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	for name, content := range map[string]string{
+		"pkg/a.go":     "package pkg // v1\n",
+		"pkg/sub/b.go": "package sub\n",
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "pkg", "a.go"), []byte("package pkg // v2\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "pkg", "c.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	cmd := exec.Command("git", "add", ".")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to stage files: %v", err)
+	}
+
+	repo := NewRepository(tmpDir)
+
+	head, err := repo.ReadFile("HEAD", "pkg/a.go")
+	if err != nil || string(head) != "package pkg // v1\n" {
+		t.Errorf("Unexpected HEAD content %q: %v", head, err)
+	}
+	staged, err := repo.ReadFile("", "pkg/a.go")
+	if err != nil || string(staged) != "package pkg // v2\n" {
+		t.Errorf("Unexpected staged content %q: %v", staged, err)
+	}
+
+	headFiles, err := repo.ListFiles("HEAD", "pkg")
+	if err != nil || strings.Join(headFiles, ",") != "pkg/a.go" {
+		t.Errorf("Unexpected HEAD files %v: %v", headFiles, err)
+	}
+	stagedFiles, err := repo.ListFiles("", "pkg")
+	if err != nil || strings.Join(stagedFiles, ",") != "pkg/a.go,pkg/c.go" {
+		t.Errorf("Unexpected staged files %v: %v", stagedFiles, err)
+	}
+}
+
+func TestCurrentBranchAndConfigValue(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, args := range [][]string{
+		{"init"},
+		{"checkout", "-b", "feature/login"},
+		{"config", "user.name", "Test User"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "auto-commit.privacy", "metadata"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	repo := NewRepository(tmpDir)
+
+	branch, err := repo.CurrentBranch()
+	if err != nil || branch != "feature/login" {
+		t.Errorf("Unexpected branch %q: %v", branch, err)
+	}
+
+	value, err := repo.ConfigValue("auto-commit.privacy")
+	if err != nil || value != "metadata" {
+		t.Errorf("Unexpected config value %q: %v", value, err)
+	}
+	value, err = repo.ConfigValue("auto-commit.unset")
+	if err != nil || value != "" {
+		t.Errorf("Expected an empty value for an unset key, got %q: %v", value, err)
+	}
+
+	for _, args := range [][]string{{"commit", "--allow-empty", "-m", "initial"}, {"checkout", "--detach"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+	branch, err = repo.CurrentBranch()
+	if err != nil || branch != "" {
+		t.Errorf("Expected no branch on a detached HEAD, got %q: %v", branch, err)
+	}
+}
@@ -0,0 +1,32 @@
+package gittest
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Files is a git.FileReader serving contents keyed by "rev:path", where an empty rev is the index
+type Files map[string]string
+
+// ReadFile returns the contents of a file at a revision
+func (f Files) ReadFile(rev, p string) ([]byte, error) {
+	src, ok := f[rev+":"+p]
+	if !ok {
+		return nil, fmt.Errorf("%s:%s does not exist", rev, p)
+	}
+	return []byte(src), nil
+}
+
+// ListFiles returns the files directly inside a directory at a revision
+func (f Files) ListFiles(rev, dir string) ([]string, error) {
+	var files []string
+	for key := range f {
+		if r, p, _ := strings.Cut(key, ":"); r == rev && path.Dir(p) == path.Clean(dir) {
+			files = append(files, p)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,330 @@
+package goapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+// API maps each exported symbol of a package to a description of its signature.
+// Struct fields and interface methods are listed as "<Type>.<Name>".
+type API map[string]string
+
+// BreakingChange is an incompatible change to an exported symbol
+type BreakingChange struct {
+	// Symbol is the package path and symbol name, such as "pkg/store.Store.Get"
+	Symbol string
+	// Reason is "removed", "signature changed" or "method added"
+	Reason string
+}
+
+func (b BreakingChange) String() string {
+	return b.Symbol + " " + b.Reason
+}
+
+// PackageAPI returns the exported API of a package from the source of its non-test files.
+// Files excluded with the ignore build tag and package main files, such as go:generate
+// programs kept next to a library, are skipped one at a time. It returns a nil API for
+// main packages, which cannot be imported.
+func PackageAPI(files map[string][]byte) (API, error) {
+	api := API{}
+	fset := token.NewFileSet()
+	mainFiles, libraryFiles := 0, 0
+	for name, src := range files {
+		file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution|parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if ignored(file) {
+			continue
+		}
+		if file.Name.Name == "main" {
+			mainFiles++
+			continue
+		}
+		libraryFiles++
+		for _, d := range file.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				addFunc(fset, api, d)
+			case *ast.GenDecl:
+				addGenDecl(fset, api, d)
+			}
+		}
+	}
+	if mainFiles > 0 && libraryFiles == 0 {
+		return nil, nil
+	}
+	return api, nil
+}
+
+// ignored reports whether a file's build constraint excludes it through the ignore tag, the
+// convention for programs that are run with go run rather than built with the package
+func ignored(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				continue
+			}
+			mentionsIgnore := false
+			// Other tags are assumed satisfiable, so only the ignore tag can exclude the file
+			satisfied := expr.Eval(func(tag string) bool {
+				if tag == "ignore" {
+					mentionsIgnore = true
+					return false
+				}
+				return true
+			})
+			if mentionsIgnore && !satisfied {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func addFunc(fset *token.FileSet, api API, d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		api[d.Name.Name] = "func" + funcType(fset, d.Type)
+		return
+	}
+
+	recv := ReceiverName(d.Recv.List[0].Type)
+	if !ast.IsExported(recv) {
+		return
+	}
+	// A pointer receiver changes the method set of the value type
+	receiver := recv
+	if _, ok := d.Recv.List[0].Type.(*ast.StarExpr); ok {
+		receiver = "*" + recv
+	}
+	api[recv+"."+d.Name.Name] = "func (" + receiver + ")" + funcType(fset, d.Type)
+}
+
+func addGenDecl(fset *token.FileSet, api API, d *ast.GenDecl) {
+	for _, spec := range d.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if !spec.Name.IsExported() {
+				continue
+			}
+			addType(fset, api, spec)
+		case *ast.ValueSpec:
+			for _, name := range spec.Names {
+				if !name.IsExported() {
+					continue
+				}
+				// Only the type is part of the API; changing a value is not breaking
+				signature := d.Tok.String()
+				if spec.Type != nil {
+					signature += " " + nodeString(fset, spec.Type)
+				}
+				api[name.Name] = signature
+			}
+		}
+	}
+}
+
+func addType(fset *token.FileSet, api API, spec *ast.TypeSpec) {
+	name := spec.Name.Name
+	typeParams := ""
+	if spec.TypeParams != nil {
+		typeParams = "[" + fieldTypes(fset, spec.TypeParams) + "]"
+	}
+
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		api[name] = "type struct" + typeParams
+		for _, field := range t.Fields.List {
+			typ := nodeString(fset, field.Type)
+			if len(field.Names) == 0 {
+				// Embedded fields are named after their type
+				if embedded := ReceiverName(field.Type); ast.IsExported(embedded) {
+					api[name+"."+embedded] = "field " + typ
+				}
+				continue
+			}
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					api[name+"."+fieldName.Name] = "field " + typ
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		api[name] = "type interface" + typeParams
+		for _, method := range t.Methods.List {
+			if len(method.Names) == 0 {
+				api[name+"."+nodeString(fset, method.Type)] = "embedded"
+				continue
+			}
+			if ft, ok := method.Type.(*ast.FuncType); ok {
+				api[name+"."+method.Names[0].Name] = "method" + funcType(fset, ft)
+			}
+		}
+	default:
+		alias := ""
+		if spec.Assign.IsValid() {
+			alias = "= "
+		}
+		api[name] = "type" + typeParams + " " + alias + nodeString(fset, spec.Type)
+	}
+}
+
+// funcType describes a function's parameter and result types, ignoring parameter names
+func funcType(fset *token.FileSet, ft *ast.FuncType) string {
+	s := ""
+	if ft.TypeParams != nil {
+		s += "[" + fieldTypes(fset, ft.TypeParams) + "]"
+	}
+	s += "(" + fieldTypes(fset, ft.Params) + ")"
+	if ft.Results != nil {
+		s += " (" + fieldTypes(fset, ft.Results) + ")"
+	}
+	return s
+}
+
+// fieldTypes lists the types in a field list, repeating a type for each name sharing it
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) string {
+	var types []string
+	for _, field := range fields.List {
+		typ := nodeString(fset, field.Type)
+		for i := 0; i < max(1, len(field.Names)); i++ {
+			types = append(types, typ)
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+// Breaking returns the incompatible differences between two versions of a package API:
+// removed symbols, changed signatures and methods added to interfaces
+func Breaking(pkg string, oldAPI, newAPI API) []BreakingChange {
+	// Symbols of the package at the repository root are not qualified
+	prefix := pkg + "."
+	if pkg == "." || pkg == "" {
+		prefix = ""
+	}
+
+	var changes []BreakingChange
+	for symbol, oldSig := range oldAPI {
+		newSig, ok := newAPI[symbol]
+		switch {
+		case !ok:
+			changes = append(changes, BreakingChange{Symbol: prefix + symbol, Reason: "removed"})
+		case newSig != oldSig:
+			changes = append(changes, BreakingChange{Symbol: prefix + symbol, Reason: "signature changed"})
+		}
+	}
+
+	// Adding a method to an existing interface breaks its implementations
+	for symbol, sig := range newAPI {
+		owner, _, ok := strings.Cut(symbol, ".")
+		if !ok || !strings.HasPrefix(oldAPI[owner], "type interface") || !strings.HasPrefix(newAPI[owner], "type interface") {
+			continue
+		}
+		if _, existed := oldAPI[symbol]; !existed && (strings.HasPrefix(sig, "method") || sig == "embedded") {
+			changes = append(changes, BreakingChange{Symbol: prefix + symbol, Reason: "method added"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Symbol < changes[j].Symbol })
+	return changes
+}
+
+// DiffBreakingChanges compares the exported API of every importable package with a changed
+// Go file between HEAD and the index. Packages that cannot be read or parsed are skipped.
+func DiffBreakingChanges(r git.FileReader, diff *git.Diff) []BreakingChange {
+	dirs := map[string]bool{}
+	for _, f := range diff.Files {
+		for _, p := range []string{f.OldPath, f.Path} {
+			if strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") {
+				dirs[path.Dir(p)] = true
+			}
+		}
+	}
+
+	var sorted []string
+	for dir := range dirs {
+		// Packages under internal/ cannot be imported from other modules
+		if dir == "internal" || strings.HasPrefix(dir, "internal/") || strings.Contains(dir, "/internal/") || strings.HasSuffix(dir, "/internal") {
+			continue
+		}
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	var changes []BreakingChange
+	for _, dir := range sorted {
+		oldAPI, err := readPackageAPI(r, "HEAD", dir)
+		if err != nil || len(oldAPI) == 0 {
+			continue
+		}
+		// A package deleted from the index has an empty API, so all its symbols count as removed
+		newAPI, err := readPackageAPI(r, "", dir)
+		if err != nil {
+			continue
+		}
+		changes = append(changes, Breaking(dir, oldAPI, newAPI)...)
+	}
+	return changes
+}
+
+func readPackageAPI(r git.FileReader, rev, dir string) (API, error) {
+	paths, err := r.ListFiles(rev, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, p := range paths {
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			continue
+		}
+		src, err := r.ReadFile(rev, p)
+		if err != nil {
+			return nil, err
+		}
+		files[p] = src
+	}
+	return PackageAPI(files)
+}
+
+// BreakingSummary describes the breaking changes for inclusion in the prompt
+func BreakingSummary(changes []BreakingChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Breaking changes to exported Go APIs:")
+	for _, c := range changes {
+		fmt.Fprintf(&b, "\n- %s", c)
+	}
+	return b.String()
+}
+
+// BreakingFooter describes the breaking changes as the value of a BREAKING CHANGE footer
+func BreakingFooter(changes []BreakingChange) string {
+	var b strings.Builder
+	b.WriteString("incompatible changes to the exported Go API")
+	for _, c := range changes {
+		fmt.Fprintf(&b, "\n- %s", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package goapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/git/gittest"
+)
+
+const oldAPISource = `package store
+
+import "context"
+
+type Store struct {
+	Name  string
+	Limit int
+	items map[string]string
+}
+
+type Getter interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+type Option func(*Store)
+
+const Version = "1.0"
+
+func New(name string, opts ...Option) *Store { return nil }
+
+func (s *Store) Get(ctx context.Context, key string) (string, error) { return "", nil }
+
+func (s *Store) Delete(key string) {}
+
+func helper() {}
+`
+
+const newAPISource = `package store
+
+import "context"
+
+type Store struct {
+	Name    string
+	items   map[string]string
+	Timeout int
+}
+
+type Getter interface {
+	Get(ctx context.Context, key string) (string, error)
+	Close() error
+}
+
+type Option func(*Store)
+
+const Version = "1.1"
+
+func New(label string, opts ...Option) *Store { return nil }
+
+func (s *Store) Get(ctx context.Context, key string) (string, bool) { return "", false }
+
+func (s *Store) Put(key, value string) {}
+
+func helper(x int) {}
+`
+
+func TestBreaking(t *testing.T) {
+	oldAPI, err := PackageAPI(map[string][]byte{"store.go": []byte(oldAPISource)})
+	if err != nil {
+		t.Fatalf("PackageAPI failed: %v", err)
+	}
+	newAPI, err := PackageAPI(map[string][]byte{"store.go": []byte(newAPISource)})
+	if err != nil {
+		t.Fatalf("PackageAPI failed: %v", err)
+	}
+
+	var got []string
+	for _, c := range Breaking("pkg/store", oldAPI, newAPI) {
+		got = append(got, c.String())
+	}
+
+	// Renamed parameters, new fields and methods, changed values and unexported changes are compatible
+	expected := []string{
+		"pkg/store.Getter.Close method added",
+		"pkg/store.Store.Delete removed",
+		"pkg/store.Store.Get signature changed",
+		"pkg/store.Store.Limit removed",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestBreaking_RootPackage(t *testing.T) {
+	changes := Breaking(".", API{"Run": "func()"}, API{})
+	if len(changes) != 1 || changes[0].Symbol != "Run" {
+		t.Errorf("Expected Run to be removed, got %v", changes)
+	}
+}
+
+func TestPackageAPI_MainPackage(t *testing.T) {
+	api, err := PackageAPI(map[string][]byte{"main.go": []byte("package main\n\nfunc Run() {}\n")})
+	if err != nil {
+		t.Fatalf("PackageAPI failed: %v", err)
+	}
+	if api != nil {
+		t.Errorf("Expected no API for a main package, got %v", api)
+	}
+}
+
+func TestPackageAPI_SkipsGeneratorFiles(t *testing.T) {
+	files := map[string][]byte{
+		"store.go": []byte("package store\n\nfunc New() {}\n"),
+		"gen.go":   []byte("//go:build ignore\n\npackage main\n\nfunc Generate() {}\n"),
+		"tool.go":  []byte("package main\n\nfunc Tool() {}\n"),
+	}
+	api, err := PackageAPI(files)
+	if err != nil {
+		t.Fatalf("PackageAPI failed: %v", err)
+	}
+	if len(api) != 1 || api["New"] == "" {
+		t.Errorf("Expected only the library API, got %v", api)
+	}
+}
+
+// generatorSource is a go:generate program kept in the directory of a library package
+const generatorSource = "//go:build ignore\n\npackage main\n\nfunc main() {}\n"
+
+func TestDiffBreakingChanges(t *testing.T) {
+	reader := gittest.Files{
+		"HEAD:store/store.go":        oldAPISource,
+		":store/store.go":            newAPISource,
+		"HEAD:store/store_test.go":   "package store\n\nfunc TestHelper() {}\n",
+		"HEAD:internal/util/util.go": "package util\n\nfunc Do() {}\n",
+		":internal/util/util.go":     "package util\n",
+	}
+	diff := &git.Diff{Files: []git.FileDiff{
+		{Path: "store/store.go", OldPath: "store/store.go", Status: git.StatusModified},
+		{Path: "store/store_test.go", OldPath: "store/store_test.go", Status: git.StatusDeleted},
+		{Path: "internal/util/util.go", OldPath: "internal/util/util.go", Status: git.StatusModified},
+	}}
+
+	changes := DiffBreakingChanges(reader, diff)
+	if len(changes) != 4 {
+		t.Fatalf("Expected 4 breaking changes in store, got %+v", changes)
+	}
+	for _, c := range changes {
+		if !strings.HasPrefix(c.Symbol, "store.") {
+			t.Errorf("Unexpected breaking change outside store: %s", c)
+		}
+	}
+
+	footer := BreakingFooter(changes[:1])
+	if footer != "incompatible changes to the exported Go API\n- store.Getter.Close method added" {
+		t.Errorf("Unexpected footer: %q", footer)
+	}
+}
+
+func TestDiffBreakingChanges_GeneratorFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader gittest.Files
+		diff   []git.FileDiff
+	}{
+		{
+			name: "generator already at HEAD",
+			reader: gittest.Files{
+				"HEAD:store/store.go": oldAPISource,
+				"HEAD:store/gen.go":   generatorSource,
+				":store/store.go":     newAPISource,
+				":store/gen.go":       generatorSource,
+			},
+			diff: []git.FileDiff{{Path: "store/store.go", OldPath: "store/store.go", Status: git.StatusModified}},
+		},
+		{
+			name: "generator newly added",
+			reader: gittest.Files{
+				"HEAD:store/store.go": oldAPISource,
+				":store/store.go":     newAPISource,
+				":store/gen.go":       generatorSource,
+			},
+			diff: []git.FileDiff{
+				{Path: "store/store.go", OldPath: "store/store.go", Status: git.StatusModified},
+				{Path: "store/gen.go", Status: git.StatusAdded},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := DiffBreakingChanges(tt.reader, &git.Diff{Files: tt.diff})
+			if len(changes) != 4 {
+				t.Errorf("Expected the 4 breaking changes in store, got %+v", changes)
+			}
+		})
+	}
+}
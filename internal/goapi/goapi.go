@@ -0,0 +1,260 @@
+package goapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+// Decl is a top-level declaration in a Go file
+type Decl struct {
+	// Kind is "func", "method", "type", "var" or "const"
+	Kind string
+	// Name is the declared name; methods are named "<Receiver>.<Method>"
+	Name     string
+	Exported bool
+	// Signature is the declaration without its body, such as "func Load(path string) (*Config, error)"
+	Signature string
+	// Source is the full declaration without comments, used to detect changes
+	Source string
+}
+
+// Change is a declaration added, removed or changed between two versions of a file
+type Change struct {
+	// Kind is "added", "removed" or "changed"
+	Kind string
+	Decl Decl
+	// SignatureChanged is set for changed declarations whose signature differs
+	SignatureChanged bool
+}
+
+// FileChanges holds the declaration changes in one file
+type FileChanges struct {
+	Path    string
+	Changes []Change
+}
+
+// ParseDecls returns the functions, methods and types declared in a Go source file,
+// plus its exported variables and constants
+func ParseDecls(filename string, src []byte) ([]Decl, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	var decls []Decl
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			decls = append(decls, funcDecl(fset, d))
+		case *ast.GenDecl:
+			decls = append(decls, genDecls(fset, d)...)
+		}
+	}
+	return decls, nil
+}
+
+func funcDecl(fset *token.FileSet, d *ast.FuncDecl) Decl {
+	decl := Decl{
+		Kind:      "func",
+		Name:      d.Name.Name,
+		Exported:  d.Name.IsExported(),
+		Signature: nodeString(fset, &ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type}),
+		Source:    nodeString(fset, &ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type, Body: d.Body}),
+	}
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv := ReceiverName(d.Recv.List[0].Type)
+		decl.Kind = "method"
+		decl.Name = recv + "." + d.Name.Name
+		decl.Exported = d.Name.IsExported() && ast.IsExported(recv)
+	}
+	return decl
+}
+
+func genDecls(fset *token.FileSet, d *ast.GenDecl) []Decl {
+	var decls []Decl
+	for _, spec := range d.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			decls = append(decls, Decl{
+				Kind:      "type",
+				Name:      spec.Name.Name,
+				Exported:  spec.Name.IsExported(),
+				Signature: typeSignature(fset, spec),
+				Source:    nodeString(fset, spec),
+			})
+		case *ast.ValueSpec:
+			kind := "var"
+			if d.Tok == token.CONST {
+				kind = "const"
+			}
+			for i, name := range spec.Names {
+				// Unexported package-level values are implementation details
+				if !name.IsExported() {
+					continue
+				}
+				signature := kind + " " + name.Name
+				if spec.Type != nil {
+					signature += " " + nodeString(fset, spec.Type)
+				}
+				source := signature
+				if i < len(spec.Values) {
+					source += " = " + nodeString(fset, spec.Values[i])
+				}
+				decls = append(decls, Decl{
+					Kind:      kind,
+					Name:      name.Name,
+					Exported:  true,
+					Signature: signature,
+					Source:    source,
+				})
+			}
+		}
+	}
+	return decls
+}
+
+// typeSignature describes a type declaration without listing struct fields or interface methods
+func typeSignature(fset *token.FileSet, spec *ast.TypeSpec) string {
+	short := *spec
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		short.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		short.Type = ast.NewIdent("interface")
+	}
+	return "type " + nodeString(fset, &short)
+}
+
+// ReceiverName returns the type name of a method receiver, without pointer or type parameters
+func ReceiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return ReceiverName(e.X)
+	case *ast.IndexExpr:
+		return ReceiverName(e.X)
+	case *ast.IndexListExpr:
+		return ReceiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+func nodeString(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Compare returns the declarations added, removed or changed between two versions of a file.
+// A nil slice stands for a file that does not exist on that side.
+func Compare(oldDecls, newDecls []Decl) []Change {
+	key := func(d Decl) string { return d.Kind + " " + d.Name }
+
+	oldByKey := map[string]Decl{}
+	for _, d := range oldDecls {
+		oldByKey[key(d)] = d
+	}
+
+	var changes []Change
+	seen := map[string]bool{}
+	for _, d := range newDecls {
+		k := key(d)
+		seen[k] = true
+		old, ok := oldByKey[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: "added", Decl: d})
+		case old.Source != d.Source:
+			changes = append(changes, Change{Kind: "changed", Decl: d, SignatureChanged: old.Signature != d.Signature})
+		}
+	}
+	for _, d := range oldDecls {
+		if !seen[key(d)] {
+			changes = append(changes, Change{Kind: "removed", Decl: d})
+		}
+	}
+
+	order := map[string]int{"removed": 0, "added": 1, "changed": 2}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return order[changes[i].Kind] < order[changes[j].Kind]
+	})
+	return changes
+}
+
+// Summary lists the changed declarations per file for inclusion in the prompt
+func Summary(files []FileChanges) string {
+	var b strings.Builder
+	for _, f := range files {
+		if len(f.Changes) == 0 {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("Go declarations changed:")
+		}
+		fmt.Fprintf(&b, "\n%s", f.Path)
+		for _, c := range f.Changes {
+			line := c.Decl.Signature
+			if c.Kind == "changed" {
+				switch {
+				case c.SignatureChanged:
+					line += " (signature changed)"
+				case c.Decl.Kind == "type":
+					line += " (definition changed)"
+				case c.Decl.Kind == "var" || c.Decl.Kind == "const":
+					line += " (value changed)"
+				default:
+					line += " (body changed)"
+				}
+			}
+			fmt.Fprintf(&b, "\n  %s: %s", c.Kind, line)
+		}
+	}
+	return b.String()
+}
+
+// DiffChanges compares the HEAD and index versions of the Go files in a diff.
+// Files that cannot be read or parsed are skipped, leaving the text diff to describe them.
+func DiffChanges(r git.FileReader, diff *git.Diff) []FileChanges {
+	var files []FileChanges
+	for _, f := range diff.Files {
+		if !strings.HasSuffix(f.Path, ".go") || f.Binary {
+			continue
+		}
+
+		var oldDecls, newDecls []Decl
+		var err error
+		if f.Status != git.StatusAdded {
+			if oldDecls, err = readDecls(r, "HEAD", f.OldPath); err != nil {
+				continue
+			}
+		}
+		if f.Status != git.StatusDeleted {
+			if newDecls, err = readDecls(r, "", f.Path); err != nil {
+				continue
+			}
+		}
+
+		files = append(files, FileChanges{Path: f.Path, Changes: Compare(oldDecls, newDecls)})
+	}
+	return files
+}
+
+func readDecls(r git.FileReader, rev, path string) ([]Decl, error) {
+	src, err := r.ReadFile(rev, path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDecls(path, src)
+}
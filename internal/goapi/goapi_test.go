@@ -0,0 +1,179 @@
+package goapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/git/gittest"
+)
+
+const oldSource = `package store
+
+import "context"
+
+const Version = "1.0"
+
+const internalLimit = 10
+
+type Store struct {
+	items map[string]string
+}
+
+type Getter[K comparable] interface {
+	Get(ctx context.Context, key K) (string, error)
+}
+
+func New() *Store {
+	return &Store{items: map[string]string{}}
+}
+
+func (s *Store) Get(ctx context.Context, key string) (string, error) {
+	return s.items[key], nil
+}
+
+func (s *Store) Delete(key string) {
+	delete(s.items, key)
+}
+
+func helper() {}
+`
+
+const newSource = `package store
+
+import "context"
+
+const Version = "1.1"
+
+const internalLimit = 20
+
+type Store struct {
+	items map[string]string
+}
+
+type Getter[K comparable] interface {
+	Get(ctx context.Context, key K) (string, error)
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{items: map[string]string{}}
+}
+
+func (s *Store) Get(ctx context.Context, key string) (string, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+func (s *Store) Put(key, value string) {
+	s.items[key] = value
+}
+
+func helper() {
+	_ = internalLimit
+}
+`
+
+func TestParseDecls(t *testing.T) {
+	decls, err := ParseDecls("store.go", []byte(oldSource))
+	if err != nil {
+		t.Fatalf("ParseDecls failed: %v", err)
+	}
+
+	signatures := map[string]string{}
+	for _, d := range decls {
+		signatures[d.Kind+" "+d.Name] = d.Signature
+	}
+
+	expected := map[string]string{
+		"const Version":       "const Version",
+		"type Store":          "type Store struct",
+		"type Getter":         "type Getter[K comparable] interface",
+		"func New":            "func New() *Store",
+		"method Store.Get":    "func (s *Store) Get(ctx context.Context, key string) (string, error)",
+		"method Store.Delete": "func (s *Store) Delete(key string)",
+		"func helper":         "func helper()",
+	}
+	if len(signatures) != len(expected) {
+		t.Errorf("Expected %d declarations, got %v", len(expected), signatures)
+	}
+	for key, want := range expected {
+		if signatures[key] != want {
+			t.Errorf("Expected %s to have signature %q, got %q", key, want, signatures[key])
+		}
+	}
+}
+
+func TestParseDecls_InvalidSource(t *testing.T) {
+	if _, err := ParseDecls("broken.go", []byte("package broken\nfunc {")); err == nil {
+		t.Error("Expected error for invalid source, got nil")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	oldDecls, err := ParseDecls("store.go", []byte(oldSource))
+	if err != nil {
+		t.Fatalf("ParseDecls failed: %v", err)
+	}
+	newDecls, err := ParseDecls("store.go", []byte(newSource))
+	if err != nil {
+		t.Fatalf("ParseDecls failed: %v", err)
+	}
+
+	summary := Summary([]FileChanges{{Path: "store/store.go", Changes: Compare(oldDecls, newDecls)}})
+
+	expected := strings.Join([]string{
+		"Go declarations changed:",
+		"store/store.go",
+		"  removed: func (s *Store) Delete(key string)",
+		"  added: func (s *Store) Put(key, value string)",
+		"  changed: const Version (value changed)",
+		"  changed: func (s *Store) Get(ctx context.Context, key string) (string, bool) (signature changed)",
+		"  changed: func helper() (body changed)",
+	}, "\n")
+	if summary != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, summary)
+	}
+}
+
+func TestCompare_NewFile(t *testing.T) {
+	newDecls, err := ParseDecls("store.go", []byte(newSource))
+	if err != nil {
+		t.Fatalf("ParseDecls failed: %v", err)
+	}
+
+	for _, c := range Compare(nil, newDecls) {
+		if c.Kind != "added" {
+			t.Errorf("Expected only added declarations, got %+v", c)
+		}
+	}
+}
+
+func TestSummary_NoChanges(t *testing.T) {
+	if summary := Summary([]FileChanges{{Path: "a.go"}}); summary != "" {
+		t.Errorf("Expected empty summary, got %q", summary)
+	}
+}
+
+func TestDiffChanges(t *testing.T) {
+	reader := gittest.Files{
+		"HEAD:store/store.go": oldSource,
+		":store/store.go":     newSource,
+		":store/broken.go":    "package store\nfunc {",
+		":store/new.go":       "package store\n\nfunc Open() {}\n",
+	}
+	diff := &git.Diff{Files: []git.FileDiff{
+		{Path: "store/store.go", OldPath: "store/store.go", Status: git.StatusModified},
+		{Path: "store/broken.go", OldPath: "store/broken.go", Status: git.StatusAdded},
+		{Path: "store/new.go", OldPath: "store/new.go", Status: git.StatusAdded},
+		{Path: "README.md", OldPath: "README.md", Status: git.StatusModified},
+	}}
+
+	files := DiffChanges(reader, diff)
+	if len(files) != 2 || files[0].Path != "store/store.go" || files[1].Path != "store/new.go" {
+		t.Fatalf("Unexpected file changes: %+v", files)
+	}
+	if len(files[1].Changes) != 1 || files[1].Changes[0].Kind != "added" || files[1].Changes[0].Decl.Name != "Open" {
+		t.Errorf("Unexpected changes for new file: %+v", files[1].Changes)
+	}
+}
@@ -0,0 +1,165 @@
+package grounding
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+)
+
+// maxFileSize is the largest changed file read as a source; larger files are left to the diff
+const maxFileSize = 1 << 20
+
+var (
+	backtickPattern = regexp.MustCompile("`([^`\\s]+)`")
+	// codeSpanPattern matches backticked names and paths, leaving out flags and expressions
+	codeSpanPattern = regexp.MustCompile(`^[A-Za-z_.][\w.]*(?:/[\w.-]+)*/?(?:\(\))?$`)
+	urlPattern      = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://\S+|\bwww\.\S+`)
+	// camelPattern matches names mixing lower and upper case, such as parseConfig or NewStore
+	camelPattern = regexp.MustCompile(`\b[A-Za-z][a-z0-9]*[A-Z][A-Za-z0-9]*\b`)
+	// snakePattern matches names joined with underscores, such as max_tokens or MAX_SIZE
+	snakePattern = regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9]*(?:_[A-Za-z0-9]+)+\b`)
+	// pathPattern matches slash-separated paths and file names with a common source extension
+	pathPattern = regexp.MustCompile(`(?:\b|\.{1,2}/)[\w.-]+(?:/[\w.-]+)+/?|\b[\w-]+\.(?:go|mod|sum|js|ts|tsx|jsx|py|rs|rb|java|c|h|cpp|cs|md|yaml|yml|json|toml|sh|sql|proto|txt)\b`)
+)
+
+// wellKnown are mixed-case words that are names of products rather than code
+var wellKnown = map[string]bool{
+	"GitHub": true, "GitLab": true, "OpenAI": true, "JavaScript": true, "TypeScript": true,
+	"PostgreSQL": true, "MySQL": true, "SQLite": true, "MongoDB": true, "GraphQL": true,
+	"macOS": true, "iOS": true, "iPhone": true, "YouTube": true, "LinkedIn": true, "PowerShell": true,
+	"DevContainer": true, "VSCode": true, "WebSocket": true, "OAuth": true, "README": true,
+}
+
+// Checker verifies that the code names mentioned in a commit message exist in the change
+type Checker struct {
+	sources []string
+}
+
+// New creates a Checker that accepts names found in any of the sources, usually the diff
+// and the contents of the changed files
+func New(sources ...string) *Checker {
+	return &Checker{sources: sources}
+}
+
+// Names returns the code-like names mentioned in a message: backticked spans, camelCase and
+// snake_case identifiers and file paths. Conventional commit type and scope are skipped.
+func Names(message string) []string {
+	body := message
+	if header, rest, _ := strings.Cut(message, "\n"); strings.Contains(header, ": ") {
+		// The scope names an area of the code rather than a symbol
+		_, subject, _ := strings.Cut(header, ": ")
+		body = subject + "\n" + rest
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		name = strings.TrimRight(strings.TrimSuffix(name, "()"), ".,:;")
+		if len(name) < 3 || seen[name] || wellKnown[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	for _, m := range backtickPattern.FindAllStringSubmatch(body, -1) {
+		if codeSpanPattern.MatchString(m[1]) {
+			add(m[1])
+		}
+	}
+	// Backticked spans are checked as a whole and URLs are left to the output checks
+	body = backtickPattern.ReplaceAllString(body, " ")
+	body = urlPattern.ReplaceAllString(body, " ")
+
+	for _, m := range pathPattern.FindAllString(body, -1) {
+		if isPath(m) {
+			add(m)
+		}
+	}
+	body = pathPattern.ReplaceAllString(body, " ")
+
+	for _, m := range camelPattern.FindAllString(body, -1) {
+		if !isAcronymPlural(m) {
+			add(m)
+		}
+	}
+	for _, m := range snakePattern.FindAllString(body, -1) {
+		add(m)
+	}
+	return names
+}
+
+// isPath tells paths apart from words joined by a slash, such as read/write
+func isPath(s string) bool {
+	return strings.HasSuffix(s, "/") || strings.Count(s, "/") >= 2 || strings.Contains(path.Base(s), ".")
+}
+
+// isAcronymPlural reports whether a word is the plural of an acronym, such as APIs or URLs
+func isAcronymPlural(s string) bool {
+	stem, ok := strings.CutSuffix(s, "s")
+	return ok && stem == strings.ToUpper(stem)
+}
+
+// Check returns the names mentioned in the message that appear in none of the sources
+func (c *Checker) Check(message string) []string {
+	var missing []string
+	for _, name := range Names(message) {
+		if !c.grounded(name) {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// grounded reports whether a name appears in a source. Selectors such as Store.Get and paths
+// are accepted when each part appears, since the diff rarely spells them out in full.
+func (c *Checker) grounded(name string) bool {
+	if c.contains(name) {
+		return true
+	}
+
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '.' || r == '/' || r == '(' || r == ')' })
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !c.contains(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Checker) contains(s string) bool {
+	for _, source := range c.sources {
+		if strings.Contains(source, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileSources returns the staged contents of the changed files, and the HEAD contents of
+// deleted files, so names in unchanged parts of a touched file are accepted
+func FileSources(r git.FileReader, diff *git.Diff) []string {
+	var sources []string
+	for _, f := range diff.Files {
+		if f.Binary {
+			continue
+		}
+		rev, p := "", f.Path
+		if f.Status == git.StatusDeleted {
+			rev, p = "HEAD", f.OldPath
+		}
+		src, err := r.ReadFile(rev, p)
+		if err != nil || len(src) > maxFileSize {
+			continue
+		}
+		sources = append(sources, string(src))
+	}
+	return sources
+}
@@ -0,0 +1,104 @@
+package grounding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/git/gittest"
+)
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{
+			name:    "identifiers and paths",
+			message: "fix(config): validate max_tokens in parseConfig\n\nLoad now rejects bad values in internal/config/config.go via `Config.Validate()`.",
+			want:    []string{"Config.Validate", "internal/config/config.go", "parseConfig", "max_tokens"},
+		},
+		{
+			name:    "scope and prose are skipped",
+			message: "feat(newStore): add read/write support to the GitHub provider\n\nThe APIs and URLs are documented in README.md.",
+			want:    []string{"README.md"},
+		},
+		{
+			name:    "URLs are left to the output checks",
+			message: "docs: link https://example.com/someThing/docs_page",
+			want:    nil,
+		},
+		{
+			name:    "multi-word backticks",
+			message: "test: run `go test ./...` with `-race`",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Names(tt.message)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Names() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	diff := "diff --git a/store/store.go b/store/store.go\n" +
+		"+func (s *Store) Get(key string) (string, bool) {\n" +
+		"+\treturn lookupValue(s.items, key)"
+	file := "package store\n\ntype Store struct{ items map[string]string }\n\nfunc lookupValue(items map[string]string, key string) (string, bool) {}\n"
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{
+			name:    "grounded in the diff",
+			message: "feat(store): return a found flag from `Store.Get`\n\nGet now calls lookupValue.",
+			want:    nil,
+		},
+		{
+			name:    "grounded in the changed file",
+			message: "feat(store): report missing keys\n\nSee store/store.go.",
+			want:    nil,
+		},
+		{
+			name:    "hallucinated names",
+			message: "feat(store): add `Store.GetOrDefault`\n\nCalls fetchRemote and updates cache_ttl in store/cache.go.",
+			want:    []string{"Store.GetOrDefault", "cache_ttl", "fetchRemote", "store/cache.go"},
+		},
+	}
+
+	checker := New(diff, file)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checker.Check(tt.message)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSources(t *testing.T) {
+	r := gittest.Files{
+		":a.go":       "package a // staged",
+		"HEAD:old.go": "package old // deleted",
+	}
+	diff := &git.Diff{Files: []git.FileDiff{
+		{Path: "a.go", OldPath: "a.go", Status: git.StatusModified},
+		{Path: "old.go", OldPath: "old.go", Status: git.StatusDeleted},
+		{Path: "logo.png", OldPath: "logo.png", Status: git.StatusAdded, Binary: true},
+		{Path: "missing.go", OldPath: "missing.go", Status: git.StatusAdded},
+	}}
+
+	got := FileSources(r, diff)
+	if strings.Join(got, "|") != "package a // staged|package old // deleted" {
+		t.Errorf("FileSources() = %q", got)
+	}
+}
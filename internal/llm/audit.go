@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/audit"
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+// AuditingProvider wraps a provider and describes every call in an audit record
+type AuditingProvider struct {
+	provider      Provider
+	name          string
+	endpoint      string
+	model         string
+	includePrompt bool
+	collect       func(audit.Record)
+}
+
+// NewAuditingProvider wraps provider, passing a record of each call to collect. The repository,
+// commit and redaction fields are left for the caller to fill in.
+func NewAuditingProvider(provider Provider, cfg *config.Config, includePrompt bool, collect func(audit.Record)) *AuditingProvider {
+	return &AuditingProvider{
+		provider:      provider,
+		name:          cfg.Provider,
+		endpoint:      providerEndpoint(cfg),
+		model:         providerModel(cfg),
+		includePrompt: includePrompt,
+		collect:       collect,
+	}
+}
+
+// GenerateCommitMessage generates a commit message with the wrapped provider and records the call
+func (p *AuditingProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	var usage Usage
+	var sent sentRequests
+	started := time.Now()
+	message, err := p.provider.GenerateCommitMessage(withSentRequests(WithUsage(ctx, &usage), &sent), diff, guidelines)
+
+	// The record describes the request bodies exactly as they were sent, retries included
+	prompt := strings.Join(sent.bodies, "\n")
+	sum := sha256.Sum256([]byte(prompt))
+	record := audit.Record{
+		Time:             started.UTC(),
+		Provider:         p.name,
+		Endpoint:         p.endpoint,
+		Model:            p.model,
+		PromptHash:       hex.EncodeToString(sum[:]),
+		PromptBytes:      len(prompt),
+		ResponseBytes:    len(message),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if p.includePrompt {
+		record.Prompt = prompt
+	}
+	p.collect(record)
+
+	return message, err
+}
+
+// sentRequests collects the bodies of the requests a provider sends for one call
+type sentRequests struct {
+	bodies []string
+}
+
+type sentRequestsKey struct{}
+
+func withSentRequests(ctx context.Context, s *sentRequests) context.Context {
+	return context.WithValue(ctx, sentRequestsKey{}, s)
+}
+
+// addSentRequest records the body of a request sent to the provider, if the context collects them
+func addSentRequest(ctx context.Context, body []byte) {
+	if s, ok := ctx.Value(sentRequestsKey{}).(*sentRequests); ok {
+		s.bodies = append(s.bodies, string(body))
+	}
+}
+
+// providerEndpoint returns the host the active provider sends prompts to, or an empty string
+// for providers that run locally
+func providerEndpoint(cfg *config.Config) string {
+	var endpoint string
+	switch {
+	case cfg.Provider == "openai" && cfg.OpenAI != nil:
+		endpoint = defaultOpenAIBaseURL
+		if cfg.OpenAI.BaseURL != "" {
+			endpoint = cfg.OpenAI.BaseURL
+		}
+	case cfg.Provider == "azure" && cfg.Azure != nil:
+		endpoint = cfg.Azure.Endpoint
+	case cfg.Provider == "claude" && cfg.Claude != nil:
+		endpoint = defaultClaudeBaseURL
+		if cfg.Claude.BaseURL != "" {
+			endpoint = cfg.Claude.BaseURL
+		}
+	case cfg.Provider == "github" && cfg.GitHub != nil:
+		endpoint = defaultGitHubBaseURL
+		if cfg.GitHub.BaseURL != "" {
+			endpoint = cfg.GitHub.BaseURL
+		}
+	default:
+		return ""
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+	return u.Host
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/audit"
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestAuditingProvider(t *testing.T) {
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add login"}}],"usage":{"prompt_tokens":120,"completion_tokens":8}}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Provider: "openai",
+		OpenAI:   &config.OpenAIConfig{APIKey: "test-key", Model: "gpt-4o", BaseURL: server.URL},
+	}
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	var records []audit.Record
+	auditing := NewAuditingProvider(provider, cfg, false, func(r audit.Record) { records = append(records, r) })
+	message, err := auditing.GenerateCommitMessage(context.Background(), "+func Login() {}", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.Provider != "openai" || r.Model != "gpt-4o" || r.Endpoint != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("Unexpected provider fields: %+v", r)
+	}
+	if r.PromptTokens != 120 || r.CompletionTokens != 8 {
+		t.Errorf("Expected 120/8 tokens, got %d/%d", r.PromptTokens, r.CompletionTokens)
+	}
+	if len(r.PromptHash) != 64 || r.PromptBytes != len(sent) || r.ResponseBytes != len(message) {
+		t.Errorf("Unexpected prompt fields: %+v", r)
+	}
+	if sum := sha256.Sum256([]byte(sent)); r.PromptHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the hash of the request body sent, got %s", r.PromptHash)
+	}
+	if r.Prompt != "" {
+		t.Error("The prompt should only be recorded when requested")
+	}
+}
+
+func TestAuditingProvider_Error(t *testing.T) {
+	failing := providerFunc(func(ctx context.Context, diff, guidelines string) (string, error) {
+		addSentRequest(ctx, []byte(`{"prompt":"`+diff+`"}`))
+		return "", errors.New("rate limited")
+	})
+
+	var records []audit.Record
+	auditing := NewAuditingProvider(failing, &config.Config{Provider: "heuristic"}, true, func(r audit.Record) { records = append(records, r) })
+	if _, err := auditing.GenerateCommitMessage(context.Background(), "diff", ""); err == nil {
+		t.Fatal("Expected the provider error to be returned")
+	}
+
+	if len(records) != 1 || records[0].Error != "rate limited" {
+		t.Fatalf("Expected a record of the failure, got %+v", records)
+	}
+	if records[0].Endpoint != "" {
+		t.Errorf("Expected no endpoint for a local provider, got %q", records[0].Endpoint)
+	}
+	if records[0].Prompt != `{"prompt":"diff"}` {
+		t.Errorf("Expected the request body to be recorded, got %q", records[0].Prompt)
+	}
+}
+
+type providerFunc func(ctx context.Context, diff, guidelines string) (string, error)
+
+func (f providerFunc) GenerateCommitMessage(ctx context.Context, diff, guidelines string) (string, error) {
+	return f(ctx, diff, guidelines)
+}
@@ -8,6 +8,15 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+const (
+	defaultAzureAPIVersion = "2024-02-15-preview"
+	// azureResponsesAPIVersion is the default when the Responses API is selected,
+	// since older API versions do not expose it
+	azureResponsesAPIVersion = "2025-04-01-preview"
 )
 
 // AzureOpenAIProvider implements the Provider interface for Azure OpenAI
@@ -15,6 +24,11 @@ type AzureOpenAIProvider struct {
 	endpoint   string
 	apiKey     string
 	deployment string
+	model      string
+	apiVersion string
+	credential *azureCredential
+	params     config.GenerationConfig
+	apiOptions config.OpenAIAPIOptions
 }
 
 // NewAzureOpenAIProvider creates a new Azure OpenAI provider
@@ -23,61 +37,115 @@ func NewAzureOpenAIProvider(endpoint, apiKey, deployment string) *AzureOpenAIPro
 		endpoint:   endpoint,
 		apiKey:     apiKey,
 		deployment: deployment,
+		model:      deployment,
+		apiVersion: defaultAzureAPIVersion,
 	}
 }
 
 // GenerateCommitMessage generates a commit message using Azure OpenAI
 func (p *AzureOpenAIProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	parts := buildPromptParts(diff, guidelines, instructionFrom(ctx))
+	return generateUntilComplete(p.params.MaxTokens, func(maxTokens int) (string, bool, error) {
+		if p.apiOptions.API == "responses" {
+			return p.respond(ctx, parts, maxTokens)
+		}
+		return p.complete(ctx, parts, maxTokens)
+	})
+}
+
+// complete sends a single chat completions request and reports whether the output was truncated
+func (p *AzureOpenAIProvider) complete(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
 	req := openAIRequest{
-		Messages: []openAIMessage{
-			{
-				Role:    "user",
-				Content: buildPromptWithGuidelines(diff, guidelines),
-			},
-		},
+		Messages: parts.openAIMessages(),
 	}
 
+	req.applyParams(p.params.WithMaxTokens(maxTokens), p.model)
+	req.applyReasoningEffort(p.apiOptions.ReasoningEffort, p.model)
+
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", false, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	addSentRequest(ctx, body)
 
 	// Construct Azure OpenAI URL
 	endpoint := strings.TrimSuffix(p.endpoint, "/")
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=2024-02-15-preview", endpoint, p.deployment)
+	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", endpoint, p.deployment, p.apiVersion)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("api-key", p.apiKey)
+	if err := p.setAuthHeader(ctx, httpReq.Header); err != nil {
+		return "", false, err
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", false, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", false, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var openAIResp openAIResponse
 	if err := json.Unmarshal(respBody, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", false, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if openAIResp.Error != nil {
-		return "", fmt.Errorf("azure OpenAI API error: %s", openAIResp.Error.Message)
+		return "", false, fmt.Errorf("azure OpenAI API error: %s", openAIResp.Error.Message)
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from Azure OpenAI")
+		return "", false, fmt.Errorf("no choices returned from Azure OpenAI")
+	}
+
+	openAIResp.logUsage(ctx, "azure")
+
+	choice := openAIResp.Choices[0]
+	return choice.Message.Content, choice.FinishReason == "length", nil
+}
+
+// setAuthHeader authenticates a request with the API key or a Microsoft Entra ID bearer token
+func (p *AzureOpenAIProvider) setAuthHeader(ctx context.Context, header http.Header) error {
+	if p.credential == nil {
+		header.Set("api-key", p.apiKey)
+		return nil
+	}
+
+	token, err := p.credential.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get Entra ID token: %w", err)
+	}
+	header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// respond sends a single Responses API request and reports whether the output was truncated
+func (p *AzureOpenAIProvider) respond(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
+	req := newResponsesRequest(p.model, parts, p.params.WithMaxTokens(maxTokens), p.apiOptions)
+	// The Responses API takes the deployment name as the model
+	req.Model = p.deployment
+
+	header := http.Header{}
+	if err := p.setAuthHeader(ctx, header); err != nil {
+		return "", false, err
+	}
+
+	endpoint := strings.TrimSuffix(p.endpoint, "/")
+	url := fmt.Sprintf("%s/openai/responses?api-version=%s", endpoint, p.apiVersion)
+
+	respBody, err := postJSON(ctx, url, header, req)
+	if err != nil {
+		return "", false, err
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	return parseResponsesResponse(ctx, respBody, "azure OpenAI", p.apiOptions.StructuredOutput)
 }
@@ -0,0 +1,277 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+const (
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com"
+	azureCognitiveScope       = "https://cognitiveservices.azure.com/.default"
+	clientAssertionType       = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// tokenExpiryMargin is how long before expiry a cached token is refreshed
+	tokenExpiryMargin = 2 * time.Minute
+	// commandTokenLifetime is assumed for command output that does not report an expiry
+	commandTokenLifetime = 5 * time.Minute
+)
+
+// accessToken is a bearer token together with its expiry time
+type accessToken struct {
+	Token     string    `json:"access_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t accessToken) valid() bool {
+	return t.Token != "" && time.Until(t.ExpiresAt) > tokenExpiryMargin
+}
+
+// azureCredential obtains Microsoft Entra ID tokens and caches them until they expire
+type azureCredential struct {
+	fetch     func(ctx context.Context) (accessToken, error)
+	cachePath string
+
+	mu    sync.Mutex
+	token accessToken
+}
+
+// newAzureCredential creates a credential for the configured Entra ID authentication method
+func newAzureCredential(auth *config.AzureAuthConfig) (*azureCredential, error) {
+	authority := firstNonEmpty(auth.AuthorityHost, os.Getenv("AZURE_AUTHORITY_HOST"), defaultAzureAuthorityHost)
+	authority = strings.TrimSuffix(authority, "/")
+
+	var fetch func(ctx context.Context) (accessToken, error)
+	switch auth.Method {
+	case "client_credentials":
+		fetch = func(ctx context.Context) (accessToken, error) {
+			return requestEntraToken(ctx, authority, auth.TenantID, url.Values{
+				"client_id":     {auth.ClientID},
+				"client_secret": {auth.ClientSecret},
+			})
+		}
+	case "workload_identity":
+		tenantID := firstNonEmpty(auth.TenantID, os.Getenv("AZURE_TENANT_ID"))
+		clientID := firstNonEmpty(auth.ClientID, os.Getenv("AZURE_CLIENT_ID"))
+		tokenFile := firstNonEmpty(auth.TokenFile, os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+		if tenantID == "" || clientID == "" || tokenFile == "" {
+			return nil, fmt.Errorf("workload identity requires tenant_id, client_id and token_file (or AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE)")
+		}
+		fetch = func(ctx context.Context) (accessToken, error) {
+			// The federated token is rotated on disk, so it is re-read on every exchange
+			assertion, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return accessToken{}, fmt.Errorf("failed to read federated token file: %w", err)
+			}
+			return requestEntraToken(ctx, authority, tenantID, url.Values{
+				"client_id":             {clientID},
+				"client_assertion_type": {clientAssertionType},
+				"client_assertion":      {strings.TrimSpace(string(assertion))},
+			})
+		}
+	case "command":
+		if strings.TrimSpace(auth.Command) == "" {
+			return nil, fmt.Errorf("azure auth command is required")
+		}
+		fetch = func(ctx context.Context) (accessToken, error) {
+			return runTokenCommand(ctx, auth.Command)
+		}
+	default:
+		return nil, fmt.Errorf("unknown azure auth method: %s", auth.Method)
+	}
+
+	return &azureCredential{
+		fetch:     fetch,
+		cachePath: tokenCachePath(auth),
+	}, nil
+}
+
+// Token returns a valid access token, using the in-memory or on-disk cache when possible
+func (c *azureCredential) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.valid() {
+		return c.token.Token, nil
+	}
+
+	if cached, ok := readCachedToken(c.cachePath); ok {
+		c.token = cached
+		return c.token.Token, nil
+	}
+
+	token, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	writeCachedToken(c.cachePath, token)
+	return token.Token, nil
+}
+
+// requestEntraToken performs a client credentials grant against the Entra ID token endpoint
+func requestEntraToken(ctx context.Context, authority, tenantID string, form url.Values) (accessToken, error) {
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", azureCognitiveScope)
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", authority, url.PathEscape(tenantID))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return accessToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return accessToken{}, fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return accessToken{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResp struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
+		return accessToken{}, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+
+	if tokenResp.AccessToken == "" {
+		if tokenResp.ErrorDescription != "" {
+			return accessToken{}, fmt.Errorf("entra ID token error: %s", tokenResp.ErrorDescription)
+		}
+		return accessToken{}, fmt.Errorf("entra ID token error: %s", resp.Status)
+	}
+
+	return accessToken{
+		Token:     tokenResp.AccessToken,
+		ExpiresAt: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// runTokenCommand runs a command such as `az account get-access-token -o json` through the shell,
+// so quoted arguments work as they do on the command line, and parses its output.
+// Both the JSON output of the Azure CLI and a bare token on stdout are accepted.
+func runTokenCommand(ctx context.Context, command string) (accessToken, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return accessToken{}, fmt.Errorf("token command failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	if !strings.HasPrefix(output, "{") {
+		if output == "" {
+			return accessToken{}, fmt.Errorf("token command produced no output")
+		}
+		return accessToken{Token: output, ExpiresAt: time.Now().Add(commandTokenLifetime)}, nil
+	}
+
+	var cliToken struct {
+		AccessToken string          `json:"accessToken"`
+		ExpiresOn   string          `json:"expiresOn"`
+		ExpiresOnTS json.RawMessage `json:"expires_on"`
+	}
+	if err := json.Unmarshal([]byte(output), &cliToken); err != nil {
+		return accessToken{}, fmt.Errorf("failed to parse token command output: %w", err)
+	}
+	if cliToken.AccessToken == "" {
+		return accessToken{}, fmt.Errorf("token command output has no accessToken")
+	}
+
+	expiresAt := time.Now().Add(commandTokenLifetime)
+	if ts, err := strconv.ParseInt(strings.Trim(string(cliToken.ExpiresOnTS), `"`), 10, 64); err == nil {
+		expiresAt = time.Unix(ts, 0)
+	} else if t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", cliToken.ExpiresOn, time.Local); err == nil {
+		expiresAt = t
+	}
+
+	return accessToken{Token: cliToken.AccessToken, ExpiresAt: expiresAt}, nil
+}
+
+// tokenCachePath returns the on-disk cache file for a credential, or "" if no cache directory is available
+func tokenCachePath(auth *config.AzureAuthConfig) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	key := strings.Join([]string{auth.Method, auth.AuthorityHost, auth.TenantID, auth.ClientID, auth.TokenFile, auth.Command}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, "git-auto-commit", "azure-token-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// readCachedToken loads a still valid token from the cache file
+func readCachedToken(path string) (accessToken, bool) {
+	if path == "" {
+		return accessToken{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return accessToken{}, false
+	}
+
+	var token accessToken
+	if err := json.Unmarshal(data, &token); err != nil || !token.valid() {
+		return accessToken{}, false
+	}
+	return token, true
+}
+
+// writeCachedToken stores a token in the cache file; failures only cost a token refresh later
+func writeCachedToken(path string, token accessToken) {
+	if path == "" {
+		return
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0600)
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+// isolateTokenCache points the user cache directory at a temporary directory
+func isolateTokenCache(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+}
+
+func TestAzureOpenAIProvider_ClientCredentials(t *testing.T) {
+	isolateTokenCache(t)
+
+	tokenRequests := 0
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if r.URL.Path != "/test-tenant/oauth2/v2.0/token" {
+			t.Errorf("Unexpected token path: %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Failed to parse form: %v", err)
+		}
+		if r.PostForm.Get("client_secret") != "test-secret" || r.PostForm.Get("scope") != azureCognitiveScope {
+			t.Errorf("Unexpected token request: %v", r.PostForm)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "entra-token", "expires_in": 3600})
+	}))
+	defer authority.Close()
+
+	resource := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != "2024-10-21" {
+			t.Errorf("Unexpected api-version: %s", r.URL.Query().Get("api-version"))
+		}
+		if r.Header.Get("Authorization") != "Bearer entra-token" || r.Header.Get("api-key") != "" {
+			t.Errorf("Unexpected auth headers: %v", r.Header)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"fix: handle errors"}}]}`))
+	}))
+	defer resource.Close()
+
+	provider, err := NewProvider(&config.Config{
+		Provider: "azure",
+		Azure: &config.AzureOpenAIConfig{
+			Endpoint:   resource.URL,
+			Deployment: "gpt-4o",
+			APIVersion: "2024-10-21",
+			Auth: &config.AzureAuthConfig{
+				Method:        "client_credentials",
+				TenantID:      "test-tenant",
+				ClientID:      "test-client",
+				ClientSecret:  "test-secret",
+				AuthorityHost: authority.URL,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+		if err != nil {
+			t.Fatalf("GenerateCommitMessage failed: %v", err)
+		}
+		if message != "fix: handle errors" {
+			t.Errorf("Unexpected message: %s", message)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("Expected the token to be cached, got %d token requests", tokenRequests)
+	}
+}
+
+func TestRunTokenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping shell command test on Windows")
+	}
+
+	token, err := runTokenCommand(context.Background(), `echo '{"accessToken":"cli-token","expires_on":4102444800}'`)
+	if err != nil {
+		t.Fatalf("runTokenCommand failed: %v", err)
+	}
+	if token.Token != "cli-token" {
+		t.Errorf("Unexpected token: %s", token.Token)
+	}
+	if !token.ExpiresAt.Equal(time.Unix(4102444800, 0)) {
+		t.Errorf("Unexpected expiry: %v", token.ExpiresAt)
+	}
+
+	token, err = runTokenCommand(context.Background(), "echo raw-token")
+	if err != nil {
+		t.Fatalf("runTokenCommand failed: %v", err)
+	}
+	if token.Token != "raw-token" || !token.valid() {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+
+	token, err = runTokenCommand(context.Background(), `printf '%s\n' "quoted argument"`)
+	if err != nil {
+		t.Fatalf("runTokenCommand failed: %v", err)
+	}
+	if token.Token != "quoted argument" {
+		t.Errorf("Expected quoted arguments to be kept together, got %q", token.Token)
+	}
+}
+
+func TestNewAzureCredential_WorkloadIdentityRequiresSettings(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+
+	if _, err := newAzureCredential(&config.AzureAuthConfig{Method: "workload_identity"}); err == nil {
+		t.Error("Expected error for incomplete workload identity settings, got nil")
+	}
+}
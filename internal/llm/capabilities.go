@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxTruncationRetries is how often a truncated response is retried with a bigger output budget
+	maxTruncationRetries = 2
+	// truncationRetryTokens is the output budget of the first retry when none was configured
+	truncationRetryTokens = 4096
+)
+
+// reasoningModelPrefixes identifies OpenAI reasoning models, which take max_completion_tokens
+// and reject temperature and top_p
+var reasoningModelPrefixes = []string{"o1", "o3", "o4", "gpt-5"}
+
+// isReasoningModel reports whether the model is an OpenAI reasoning model
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, prefix := range reasoningModelPrefixes {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// generateUntilComplete calls generate and, while the output was cut off at the token limit,
+// retries with a bigger budget instead of returning a truncated commit message
+func generateUntilComplete(maxTokens int, generate func(maxTokens int) (string, bool, error)) (string, error) {
+	for attempt := 0; ; attempt++ {
+		message, truncated, err := generate(maxTokens)
+		if err != nil {
+			return "", err
+		}
+		if !truncated {
+			return message, nil
+		}
+
+		if attempt == maxTruncationRetries {
+			return "", fmt.Errorf("commit message was truncated at %d output tokens; increase max_tokens", maxTokens)
+		}
+
+		if maxTokens == 0 {
+			maxTokens = truncationRetryTokens
+		} else {
+			maxTokens *= 2
+		}
+		debugf("response was truncated, retrying with max_tokens=%d", maxTokens)
+	}
+}
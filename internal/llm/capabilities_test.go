@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestIsReasoningModel(t *testing.T) {
+	testCases := map[string]bool{
+		"o1":                 true,
+		"o3-mini":            true,
+		"o4-mini-2025-04-16": true,
+		"gpt-5":              true,
+		"openai/o3":          true,
+		"gpt-4o":             false,
+		"gpt-4":              false,
+		"omni-custom":        false,
+	}
+
+	for model, want := range testCases {
+		if got := isReasoningModel(model); got != want {
+			t.Errorf("isReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestOpenAIProvider_ReasoningModelParams(t *testing.T) {
+	temperature := 0.2
+	req := openAIRequest{}
+	req.applyParams(config.GenerationConfig{Temperature: &temperature, MaxTokens: 800}, "o3-mini")
+
+	if req.Temperature != nil || req.MaxTokens != 0 || req.MaxCompletionTokens != 800 {
+		t.Errorf("Unexpected reasoning model request: %+v", req)
+	}
+}
+
+func TestOpenAIProvider_RetriesTruncatedOutput(t *testing.T) {
+	var budgets []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		budgets = append(budgets, req.MaxTokens)
+
+		choice := openAIChoice{Message: openAIMessage{Role: "assistant", Content: "feat: add"}, FinishReason: "length"}
+		if len(budgets) == 2 {
+			choice = openAIChoice{Message: openAIMessage{Role: "assistant", Content: "feat: add parser"}, FinishReason: "stop"}
+		}
+		json.NewEncoder(w).Encode(openAIResponse{Choices: []openAIChoice{choice}})
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "gpt-4o")
+	provider.baseURL = server.URL
+
+	message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+
+	if message != "feat: add parser" {
+		t.Errorf("Unexpected message: %s", message)
+	}
+	if len(budgets) != 2 || budgets[0] != 0 || budgets[1] != truncationRetryTokens {
+		t.Errorf("Unexpected output budgets: %v", budgets)
+	}
+}
+
+func TestClaudeProvider_ExtendedThinking(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req claudeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Thinking == nil || req.Thinking.BudgetTokens != 2048 || req.MaxTokens <= 2048 {
+			t.Errorf("Unexpected thinking settings: %+v (max_tokens %d)", req.Thinking, req.MaxTokens)
+		}
+
+		w.Write([]byte(`{"content":[{"type":"thinking","thinking":"The diff adds a parser."},{"type":"text","text":"feat: add parser"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider("test-key", "claude-sonnet-4-5")
+	provider.baseURL = server.URL
+	provider.thinkingBudget = 2048
+
+	message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if message != "feat: add parser" || requests != 1 {
+		t.Errorf("Unexpected message %q after %d requests", message, requests)
+	}
+}
+
+func TestClaudeProvider_ExtendedThinkingRetriesTruncatedOutput(t *testing.T) {
+	var budgets []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req claudeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		budgets = append(budgets, req.MaxTokens)
+
+		if len(budgets) == 1 {
+			w.Write([]byte(`{"content":[{"type":"thinking","thinking":"The diff adds"}],"stop_reason":"max_tokens"}`))
+			return
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"feat: add parser"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider("test-key", "claude-sonnet-4-5")
+	provider.baseURL = server.URL
+	provider.thinkingBudget = 2048
+
+	message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if message != "feat: add parser" {
+		t.Errorf("Unexpected message: %s", message)
+	}
+	// The retry doubles the limit that was sent, not the unset configured one
+	if len(budgets) != 2 || budgets[0] != 2048+defaultClaudeMaxTokens || budgets[1] != 2*(2048+defaultClaudeMaxTokens) {
+		t.Errorf("Unexpected output budgets: %v", budgets)
+	}
+}
+
+func TestGenerateUntilComplete_GivesUp(t *testing.T) {
+	calls := 0
+	_, err := generateUntilComplete(100, func(maxTokens int) (string, bool, error) {
+		calls++
+		return "feat: add", true, nil
+	})
+
+	if err == nil {
+		t.Error("Expected error for persistently truncated output, got nil")
+	}
+	if calls != maxTruncationRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", maxTruncationRetries+1, calls)
+	}
+}
@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette holds recorded provider interactions that can be served back by the replay provider
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Hash     string           `yaml:"hash"`
+	Request  CassetteRequest  `yaml:"request"`
+	Response CassetteResponse `yaml:"response"`
+}
+
+// CassetteRequest is the redacted input that was sent to a provider
+type CassetteRequest struct {
+	Provider   string `yaml:"provider,omitempty"`
+	Model      string `yaml:"model,omitempty"`
+	Diff       string `yaml:"diff"`
+	Guidelines string `yaml:"guidelines,omitempty"`
+	// Instruction is the trusted instruction added to the prompt, such as retry feedback
+	Instruction string `yaml:"instruction,omitempty"`
+}
+
+// CassetteResponse is the recorded provider output
+type CassetteResponse struct {
+	Message string `yaml:"message,omitempty"`
+	Error   string `yaml:"error,omitempty"`
+}
+
+// LoadCassette reads a cassette from disk
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+
+	return &c, nil
+}
+
+// loadOrCreateCassette reads a cassette, returning an empty one if the file does not exist yet
+func loadOrCreateCassette(path string) (*Cassette, error) {
+	c, err := LoadCassette(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Cassette{}, nil
+	}
+	return c, err
+}
+
+// Save writes the cassette to disk
+func (c *Cassette) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// Find returns the interaction recorded for the given request hash
+func (c *Cassette) Find(hash string) (*Interaction, bool) {
+	for i := range c.Interactions {
+		if c.Interactions[i].Hash == hash {
+			return &c.Interactions[i], true
+		}
+	}
+	return nil, false
+}
+
+// Put adds an interaction, replacing any previous recording of the same request
+func (c *Cassette) Put(interaction Interaction) {
+	if existing, ok := c.Find(interaction.Hash); ok {
+		*existing = interaction
+		return
+	}
+	c.Interactions = append(c.Interactions, interaction)
+}
+
+// requestHash identifies a request independently of the provider that served it. Requests
+// without an instruction hash as they did before instructions were recorded.
+func requestHash(diff, guidelines, instruction string) string {
+	key := diff + "\x00" + guidelines
+	if instruction != "" {
+		key += "\x00" + instruction
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// shortHash abbreviates a request hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// cassetteRedactor redacts requests the same way when they are recorded and when they are
+// replayed: with the configured redaction rules and every credential in the configuration
+func cassetteRedactor(cfg *config.Config) (*redact.Redactor, error) {
+	r, err := redact.New(cfg.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure redaction: %w", err)
+	}
+	r.AddValues("config-secret", configSecrets(cfg)...)
+	return r, nil
+}
+
+// placeholderPattern matches the placeholders left by the redactor
+var placeholderPattern = regexp.MustCompile(`\[REDACTED:[a-z-]+\]`)
+
+// redactText returns s with the secrets found by r replaced by one placeholder, whichever rule
+// found them, since the replay configuration may lack the credentials that matched when recording
+func redactText(r *redact.Redactor, s string) string {
+	redacted, _ := r.Redact(s)
+	return placeholderPattern.ReplaceAllString(redacted, "[REDACTED]")
+}
+
+// diffLines returns the lines removed from a ("-") and added in b ("+")
+func diffLines(a, b string) string {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			result.WriteString("+ " + y[j] + "\n")
+			j++
+		default:
+			result.WriteString("- " + x[i] + "\n")
+			i++
+		}
+	}
+
+	return result.String()
+}
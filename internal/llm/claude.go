@@ -7,26 +7,90 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
 )
 
+const defaultClaudeBaseURL = "https://api.anthropic.com"
+
 // ClaudeProvider implements the Provider interface for Anthropic Claude
 type ClaudeProvider struct {
-	apiKey string
-	model  string
+	apiKey         string
+	model          string
+	baseURL        string
+	params         config.GenerationConfig
+	thinkingBudget int
 }
 
 // NewClaudeProvider creates a new Claude provider
 func NewClaudeProvider(apiKey, model string) *ClaudeProvider {
 	return &ClaudeProvider{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: defaultClaudeBaseURL,
 	}
 }
 
+const defaultClaudeMaxTokens = 1024
+
 type claudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []claudeMessage `json:"messages"`
+	Model         string              `json:"model"`
+	MaxTokens     int                 `json:"max_tokens"`
+	System        []claudeSystemBlock `json:"system,omitempty"`
+	Messages      []claudeMessage     `json:"messages"`
+	Temperature   *float64            `json:"temperature,omitempty"`
+	TopP          *float64            `json:"top_p,omitempty"`
+	StopSequences []string            `json:"stop_sequences,omitempty"`
+	Thinking      *claudeThinking     `json:"thinking,omitempty"`
+}
+
+type claudeSystemBlock struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *claudeCacheControl `json:"cache_control,omitempty"`
+}
+
+type claudeCacheControl struct {
+	Type string `json:"type"`
+}
+
+type claudeThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// applyParams maps the generation parameters onto a messages request. With extended
+// thinking enabled, max_tokens has to cover the thinking budget and sampling parameters are rejected.
+func (r *claudeRequest) applyParams(params config.GenerationConfig, thinkingBudget int) {
+	r.MaxTokens = claudeMaxTokens(params.MaxTokens, thinkingBudget)
+	r.StopSequences = params.Stop
+	if params.Seed != nil {
+		debugf("claude: seed is not supported by the Anthropic API, dropping it")
+	}
+
+	if thinkingBudget == 0 {
+		r.Temperature = params.Temperature
+		r.TopP = params.TopP
+		return
+	}
+
+	r.Thinking = &claudeThinking{Type: "enabled", BudgetTokens: thinkingBudget}
+	if params.Temperature != nil || params.TopP != nil {
+		debugf("claude: temperature and top_p are not supported with extended thinking, dropping them")
+	}
+}
+
+// claudeMaxTokens returns the max_tokens sent for a configured limit: the default when none is
+// set, and with extended thinking enough to leave room for the answer after the thinking budget
+func claudeMaxTokens(maxTokens, thinkingBudget int) int {
+	if maxTokens == 0 {
+		maxTokens = defaultClaudeMaxTokens
+	}
+	if thinkingBudget > 0 && maxTokens <= thinkingBudget {
+		maxTokens = thinkingBudget + defaultClaudeMaxTokens
+	}
+	return maxTokens
 }
 
 type claudeMessage struct {
@@ -34,10 +98,20 @@ type claudeMessage struct {
 	Content string `json:"content"`
 }
 
+type claudeContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
 type claudeResponse struct {
-	Content []struct {
-		Text string `json:"text"`
-	} `json:"content"`
+	Content    []claudeContentBlock `json:"content"`
+	StopReason string               `json:"stop_reason"`
+	Usage      struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
@@ -45,25 +119,45 @@ type claudeResponse struct {
 
 // GenerateCommitMessage generates a commit message using Claude
 func (p *ClaudeProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	parts := buildPromptParts(diff, guidelines, instructionFrom(ctx))
+	// Retries grow the limit that was actually sent, which is always above the thinking budget
+	return generateUntilComplete(claudeMaxTokens(p.params.MaxTokens, p.thinkingBudget), func(maxTokens int) (string, bool, error) {
+		return p.complete(ctx, parts, maxTokens)
+	})
+}
+
+// complete sends a single messages request and reports whether the output was truncated
+func (p *ClaudeProvider) complete(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
 	req := claudeRequest{
-		Model:     p.model,
-		MaxTokens: 1024,
+		Model: p.model,
+		// The instructions and guidelines are identical for every commit in a repository,
+		// so they are marked as a cacheable prefix ahead of the diff
+		System: []claudeSystemBlock{
+			{
+				Type:         "text",
+				Text:         parts.system,
+				CacheControl: &claudeCacheControl{Type: "ephemeral"},
+			},
+		},
 		Messages: []claudeMessage{
 			{
 				Role:    "user",
-				Content: buildPromptWithGuidelines(diff, guidelines),
+				Content: parts.user,
 			},
 		},
 	}
 
+	req.applyParams(p.params.WithMaxTokens(maxTokens), p.thinkingBudget)
+
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", false, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	addSentRequest(ctx, body)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -73,27 +167,40 @@ func (p *ClaudeProvider) GenerateCommitMessage(ctx context.Context, diff string,
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", false, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", false, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var claudeResp claudeResponse
 	if err := json.Unmarshal(respBody, &claudeResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", false, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if claudeResp.Error != nil {
-		return "", fmt.Errorf("claude API error: %s", claudeResp.Error.Message)
+		return "", false, fmt.Errorf("claude API error: %s", claudeResp.Error.Message)
+	}
+
+	usage := claudeResp.Usage
+	addUsage(ctx, usage.InputTokens+usage.CacheCreationInputTokens+usage.CacheReadInputTokens, usage.OutputTokens)
+	debugf("claude: input tokens %d, output tokens %d, cache write %d, cache read %d",
+		usage.InputTokens, usage.OutputTokens, usage.CacheCreationInputTokens, usage.CacheReadInputTokens)
+
+	// With extended thinking the response starts with thinking blocks, so only text blocks are used
+	var text strings.Builder
+	for _, block := range claudeResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
 	}
 
-	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("no content returned from Claude")
+	if text.Len() == 0 && claudeResp.StopReason != "max_tokens" {
+		return "", false, fmt.Errorf("no content returned from Claude")
 	}
 
-	return claudeResp.Content[0].Text, nil
+	return text.String(), claudeResp.StopReason == "max_tokens", nil
 }
@@ -7,42 +7,57 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
 )
 
+const defaultGitHubBaseURL = "https://models.inference.ai.azure.com"
+
 // GitHubProvider implements the Provider interface for GitHub Models
 type GitHubProvider struct {
-	token string
-	model string
+	token      string
+	model      string
+	baseURL    string
+	catalogURL string
+	params     config.GenerationConfig
 }
 
 // NewGitHubProvider creates a new GitHub Models provider
 func NewGitHubProvider(token, model string) *GitHubProvider {
 	return &GitHubProvider{
-		token: token,
-		model: model,
+		token:      token,
+		model:      model,
+		baseURL:    defaultGitHubBaseURL,
+		catalogURL: defaultGitHubCatalogURL,
 	}
 }
 
 // GenerateCommitMessage generates a commit message using GitHub Models
 func (p *GitHubProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	parts := buildPromptParts(diff, guidelines, instructionFrom(ctx))
+	return generateUntilComplete(p.params.MaxTokens, func(maxTokens int) (string, bool, error) {
+		return p.complete(ctx, parts, maxTokens)
+	})
+}
+
+// complete sends a single chat completions request and reports whether the output was truncated
+func (p *GitHubProvider) complete(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
 	req := openAIRequest{
-		Model: p.model,
-		Messages: []openAIMessage{
-			{
-				Role:    "user",
-				Content: buildPromptWithGuidelines(diff, guidelines),
-			},
-		},
+		Model:    p.model,
+		Messages: parts.openAIMessages(),
 	}
 
+	req.applyParams(p.params.WithMaxTokens(maxTokens), p.model)
+
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", false, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	addSentRequest(ctx, body)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://models.inference.ai.azure.com/chat/completions", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -51,27 +66,30 @@ func (p *GitHubProvider) GenerateCommitMessage(ctx context.Context, diff string,
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", false, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", false, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var openAIResp openAIResponse
 	if err := json.Unmarshal(respBody, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", false, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if openAIResp.Error != nil {
-		return "", fmt.Errorf("GitHub Models API error: %s", openAIResp.Error.Message)
+		return "", false, fmt.Errorf("GitHub Models API error: %s", openAIResp.Error.Message)
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from GitHub Models")
+		return "", false, fmt.Errorf("no choices returned from GitHub Models")
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	openAIResp.logUsage(ctx, "github")
+
+	choice := openAIResp.Choices[0]
+	return choice.Message.Content, choice.FinishReason == "length", nil
 }
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubURL is the GitHub web URL that hosts the OAuth device flow endpoints
+const DefaultGitHubURL = "https://github.com"
+
+// resolveGitHubToken finds a GitHub token when none is configured, checking the
+// GITHUB_TOKEN and GH_TOKEN environment variables and then the GitHub CLI
+func resolveGitHubToken() (string, error) {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token, nil
+		}
+	}
+
+	if _, err := exec.LookPath("gh"); err == nil {
+		cmd := exec.Command("gh", "auth", "token")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		if err := cmd.Run(); err == nil {
+			if token := strings.TrimSpace(stdout.String()); token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no GitHub token found: set github.token, GITHUB_TOKEN or GH_TOKEN, log in with 'gh auth login', or run 'git-auto-commit login'")
+}
+
+// DeviceCode is the response to a device authorization request
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// defaultPollInterval is the polling interval RFC 8628 prescribes when the server gives none,
+// and the amount it is increased by on slow_down
+const defaultPollInterval = 5 * time.Second
+
+// GitHubDeviceFlow implements the OAuth device authorization grant against GitHub
+type GitHubDeviceFlow struct {
+	baseURL  string
+	clientID string
+	scope    string
+	// wait pauses between polls; tests replace it to avoid sleeping
+	wait func(ctx context.Context, d time.Duration) error
+}
+
+// NewGitHubDeviceFlow creates a device flow for the OAuth app with the given client ID.
+// baseURL is the GitHub web URL, which can point at a local stand-in server for testing.
+func NewGitHubDeviceFlow(baseURL, clientID, scope string) *GitHubDeviceFlow {
+	return &GitHubDeviceFlow{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		clientID: clientID,
+		scope:    scope,
+		wait:     sleepContext,
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// RequestCode starts the device flow and returns the code the user has to enter
+func (f *GitHubDeviceFlow) RequestCode(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{"client_id": {f.clientID}}
+	if f.scope != "" {
+		form.Set("scope", f.scope)
+	}
+
+	var code DeviceCode
+	if err := f.postForm(ctx, "/login/device/code", form, &code); err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	if code.DeviceCode == "" {
+		return nil, fmt.Errorf("failed to request device code: empty response")
+	}
+
+	return &code, nil
+}
+
+// PollToken waits until the user has authorized the device and returns the access token
+func (f *GitHubDeviceFlow) PollToken(ctx context.Context, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for {
+		var resp struct {
+			AccessToken      string `json:"access_token"`
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+			Interval         int    `json:"interval"`
+		}
+		form := url.Values{
+			"client_id":   {f.clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}
+		if err := f.postForm(ctx, "/login/oauth/access_token", form, &resp); err != nil {
+			return "", fmt.Errorf("failed to poll for access token: %w", err)
+		}
+
+		switch resp.Error {
+		case "":
+			if resp.AccessToken == "" {
+				return "", fmt.Errorf("failed to poll for access token: empty response")
+			}
+			return resp.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			// GitHub sends the increased interval; the spec requires at least 5s more either way
+			interval = max(interval+defaultPollInterval, time.Duration(resp.Interval)*time.Second)
+		default:
+			return "", fmt.Errorf("device flow failed: %s", firstNonEmpty(resp.ErrorDescription, resp.Error))
+		}
+
+		if code.ExpiresIn > 0 && time.Now().Add(interval).After(deadline) {
+			return "", fmt.Errorf("device flow failed: the device code expired")
+		}
+
+		if err := f.wait(ctx, interval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// postForm posts a form to the GitHub OAuth endpoints and decodes the JSON response into out
+func (f *GitHubDeviceFlow) postForm(ctx context.Context, path string, form url.Values, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", f.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
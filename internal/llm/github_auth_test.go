@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestGitHubDeviceFlow(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Unexpected Accept header: %s", r.Header.Get("Accept"))
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Failed to parse form: %v", err)
+		}
+		if r.PostForm.Get("client_id") != "test-client" {
+			t.Errorf("Unexpected client_id: %s", r.PostForm.Get("client_id"))
+		}
+
+		switch r.URL.Path {
+		case "/login/device/code":
+			json.NewEncoder(w).Encode(map[string]any{
+				"device_code":      "device-123",
+				"user_code":        "ABCD-1234",
+				"verification_uri": "https://github.com/login/device",
+				"expires_in":       900,
+				"interval":         0,
+			})
+		case "/login/oauth/access_token":
+			polls++
+			if r.PostForm.Get("device_code") != "device-123" {
+				t.Errorf("Unexpected device_code: %s", r.PostForm.Get("device_code"))
+			}
+			switch polls {
+			case 1:
+				json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+				return
+			case 2:
+				json.NewEncoder(w).Encode(map[string]string{"error": "slow_down"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_test", "token_type": "bearer"})
+		default:
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	flow := NewGitHubDeviceFlow(server.URL+"/", "test-client", "")
+	var waits []time.Duration
+	flow.wait = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	code, err := flow.RequestCode(context.Background())
+	if err != nil {
+		t.Fatalf("RequestCode failed: %v", err)
+	}
+	if code.UserCode != "ABCD-1234" {
+		t.Errorf("Unexpected user code: %s", code.UserCode)
+	}
+
+	token, err := flow.PollToken(context.Background(), code)
+	if err != nil {
+		t.Fatalf("PollToken failed: %v", err)
+	}
+	if token != "gho_test" || polls != 3 {
+		t.Errorf("Unexpected token %q after %d polls", token, polls)
+	}
+
+	// A missing interval defaults to 5s, and slow_down adds 5s
+	if len(waits) != 2 || waits[0] != 5*time.Second || waits[1] != 10*time.Second {
+		t.Errorf("Unexpected poll intervals: %v", waits)
+	}
+}
+
+func TestGitHubDeviceFlow_AccessDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":             "access_denied",
+			"error_description": "The authorization request was denied.",
+		})
+	}))
+	defer server.Close()
+
+	flow := NewGitHubDeviceFlow(server.URL, "test-client", "")
+	if _, err := flow.PollToken(context.Background(), &DeviceCode{DeviceCode: "device-123"}); err == nil {
+		t.Error("Expected error for denied authorization, got nil")
+	}
+}
+
+func TestNewProvider_GitHubTokenFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "env-token")
+
+	provider, err := NewProvider(&config.Config{
+		Provider: "github",
+		GitHub:   &config.GitHubConfig{Model: "gpt-4o"},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	if token := provider.(*GitHubProvider).token; token != "env-token" {
+		t.Errorf("Expected token from GH_TOKEN, got %q", token)
+	}
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/classify"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/mechanical"
+)
+
+// maxHeuristicBodyFiles is the number of files listed in the body of a heuristic message
+const maxHeuristicBodyFiles = 20
+
+// genericDirs are path segments too generic to serve as a commit scope
+var genericDirs = map[string]bool{"internal": true, "pkg": true, "src": true, "lib": true, "cmd": true, "app": true}
+
+// HeuristicProvider implements the Provider interface with rules instead of a model,
+// deriving the message from the file classification, paths and change statistics
+type HeuristicProvider struct {
+	// mechanicalMinFiles is the number of files a mechanical pattern must span to describe the change
+	mechanicalMinFiles int
+}
+
+// NewHeuristicProvider creates a new heuristic provider
+func NewHeuristicProvider() *HeuristicProvider {
+	return &HeuristicProvider{mechanicalMinFiles: mechanical.DefaultMinFiles}
+}
+
+// GenerateCommitMessage builds a commit message from the structure of the diff
+func (p *HeuristicProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	// Summaries placed ahead of the diff are skipped by the parser
+	parsed := git.ParseDiff(diff)
+	if len(parsed.Files) == 0 {
+		return "", fmt.Errorf("no file changes found in the diff")
+	}
+	if message, ok := p.mechanicalMessage(parsed); ok {
+		return message, nil
+	}
+
+	commitType, scope := heuristicType(parsed)
+	if scope == "" {
+		scope = commonScope(parsed.Files)
+	}
+
+	subject := commitType
+	if scope != "" {
+		subject += "(" + scope + ")"
+	}
+	subject += ": " + heuristicSubject(parsed.Files, commitType, scope)
+
+	return subject + "\n\n" + heuristicBody(parsed.Files), nil
+}
+
+// mechanicalMessage describes a change made only of mechanical patterns by its largest pattern
+func (p *HeuristicProvider) mechanicalMessage(diff *git.Diff) (string, bool) {
+	if p.mechanicalMinFiles <= 0 {
+		return "", false
+	}
+	patterns := mechanical.Detect(diff, p.mechanicalMinFiles, nil)
+	covered := 0
+	for _, pattern := range patterns {
+		covered += len(pattern.Files)
+	}
+	if len(patterns) == 0 || covered != len(diff.Files) {
+		return "", false
+	}
+
+	commitType := "refactor"
+	if patterns[0].Kind == "format" {
+		commitType = "style"
+	}
+	return commitType + ": " + patterns[0].String() + "\n\n" + heuristicBody(diff.Files), true
+}
+
+// heuristicType picks the commit type from the file classification, or from the file statuses
+func heuristicType(diff *git.Diff) (string, string) {
+	if c, ok := classify.Classify(diff); ok {
+		return c.Type, c.Scope
+	}
+
+	counts := statusCounts(diff.Files)
+	switch {
+	case counts[git.StatusAdded] == len(diff.Files):
+		return "feat", ""
+	case counts[git.StatusDeleted] == len(diff.Files):
+		return "chore", ""
+	case counts[git.StatusRenamed] == len(diff.Files):
+		return "refactor", ""
+	}
+	return "chore", ""
+}
+
+func statusCounts(files []git.FileDiff) map[git.FileStatus]int {
+	counts := map[git.FileStatus]int{}
+	for _, f := range files {
+		counts[f.Status]++
+	}
+	return counts
+}
+
+// commonScope returns the last meaningful segment of the directory shared by all files
+func commonScope(files []git.FileDiff) string {
+	common := strings.Split(path.Dir(files[0].Path), "/")
+	for _, f := range files[1:] {
+		dir := strings.Split(path.Dir(f.Path), "/")
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	for i := len(common) - 1; i >= 0; i-- {
+		if segment := common[i]; segment != "." && !genericDirs[segment] && !strings.HasPrefix(segment, ".") {
+			return segment
+		}
+	}
+	return ""
+}
+
+// heuristicSubject describes the dominant change, such as "add parser.go" or "update 4 files"
+func heuristicSubject(files []git.FileDiff, commitType, scope string) string {
+	if scope == "deps" {
+		return "update dependencies"
+	}
+
+	counts := statusCounts(files)
+	verb := "update"
+	switch {
+	case counts[git.StatusAdded] > len(files)/2:
+		verb = "add"
+	case counts[git.StatusDeleted] > len(files)/2:
+		verb = "remove"
+	case counts[git.StatusRenamed] > len(files)/2:
+		verb = "move"
+	}
+
+	if len(files) == 1 {
+		f := files[0]
+		if f.Status == git.StatusRenamed {
+			return fmt.Sprintf("rename %s to %s", path.Base(f.OldPath), path.Base(f.Path))
+		}
+		return verb + " " + path.Base(f.Path)
+	}
+
+	noun := "files"
+	switch commitType {
+	case "test":
+		noun = "tests"
+	case "docs":
+		noun = "documentation files"
+	case "ci":
+		noun = "CI files"
+	}
+	return fmt.Sprintf("%s %d %s", verb, len(files), noun)
+}
+
+// heuristicBody lists the changed files with their line statistics
+func heuristicBody(files []git.FileDiff) string {
+	var lines []string
+	for i, f := range files {
+		if i == maxHeuristicBodyFiles {
+			lines = append(lines, fmt.Sprintf("- and %d more files", len(files)-i))
+			break
+		}
+
+		stats := fmt.Sprintf("+%d -%d", f.Added, f.Deleted)
+		if f.Binary {
+			stats = "binary"
+		}
+		switch f.Status {
+		case git.StatusModified:
+			lines = append(lines, fmt.Sprintf("- %s (%s)", f.Path, stats))
+		case git.StatusRenamed, git.StatusCopied:
+			lines = append(lines, fmt.Sprintf("- %s -> %s (%s, %s)", f.OldPath, f.Path, f.Status, stats))
+		default:
+			lines = append(lines, fmt.Sprintf("- %s (%s, %s)", f.Path, f.Status, stats))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
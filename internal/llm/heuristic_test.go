@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestHeuristicProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "single new file",
+			diff: "diff --git a/internal/store/cache.go b/internal/store/cache.go\n" +
+				"new file mode 100644\n" +
+				"--- /dev/null\n" +
+				"+++ b/internal/store/cache.go\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+package store\n" +
+				"+type Cache struct{}",
+			want: "feat(store): add cache.go\n\n- internal/store/cache.go (added, +2 -0)",
+		},
+		{
+			name: "tests with summary ahead of the diff",
+			diff: "Suggested commit type: test (only test files changed)\n\n" +
+				"diff --git a/api/a_test.go b/api/a_test.go\n" +
+				"--- a/api/a_test.go\n" +
+				"+++ b/api/a_test.go\n" +
+				"@@ -1,1 +1,1 @@\n" +
+				"-func TestA(t *testing.T) {}\n" +
+				"+func TestA(t *testing.T) { t.Parallel() }\n" +
+				"diff --git a/api/b_test.go b/api/b_test.go\n" +
+				"--- a/api/b_test.go\n" +
+				"+++ b/api/b_test.go\n" +
+				"@@ -1,0 +1,1 @@\n" +
+				"+func TestB(t *testing.T) {}",
+			want: "test(api): update 2 tests\n\n- api/a_test.go (+1 -1)\n- api/b_test.go (+1 -0)",
+		},
+		{
+			name: "rename",
+			diff: "diff --git a/pkg/util.go b/pkg/helpers.go\n" +
+				"similarity index 100%\n" +
+				"rename from pkg/util.go\n" +
+				"rename to pkg/helpers.go",
+			want: "refactor: rename util.go to helpers.go\n\n- pkg/util.go -> pkg/helpers.go (renamed, +0 -0)",
+		},
+	}
+
+	provider, err := NewProvider(&config.Config{Provider: "heuristic"})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := provider.GenerateCommitMessage(context.Background(), tt.diff, "")
+			if err != nil {
+				t.Fatalf("GenerateCommitMessage failed: %v", err)
+			}
+			if message != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, message)
+			}
+		})
+	}
+}
+
+func TestHeuristicProvider_NoFiles(t *testing.T) {
+	if _, err := NewHeuristicProvider().GenerateCommitMessage(context.Background(), "", ""); err == nil {
+		t.Error("Expected error for an empty diff, got nil")
+	}
+}
+
+func TestHeuristicProvider_MechanicalOnly(t *testing.T) {
+	var diff strings.Builder
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(&diff, "diff --git a/store/f%d.go b/store/f%d.go\n"+
+			"--- a/store/f%d.go\n"+
+			"+++ b/store/f%d.go\n"+
+			"@@ -1,1 +1,1 @@\n"+
+			"-var s = NewStore()\n"+
+			"+var s = NewCache()\n", i, i, i, i)
+	}
+
+	message, err := NewHeuristicProvider().GenerateCommitMessage(context.Background(), diff.String(), "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if want := "refactor: rename NewStore to NewCache across 6 files\n\n- store/f0.go (+1 -1)"; !strings.HasPrefix(message, want) {
+		t.Errorf("Expected %q, got %q", want, message)
+	}
+}
+
+func TestHeuristicProvider_LockfileOnly(t *testing.T) {
+	diff := "diff --git a/go.sum b/go.sum\n" +
+		"--- a/go.sum\n" +
+		"+++ b/go.sum\n" +
+		"@@ -1,1 +1,2 @@\n" +
+		"-golang.org/x/sys v0.1.0 h1:abc=\n" +
+		"+golang.org/x/sys v0.2.0 h1:def=\n" +
+		"+golang.org/x/sys v0.2.0/go.mod h1:ghi="
+
+	message, err := NewHeuristicProvider().GenerateCommitMessage(context.Background(), diff, "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if want := "chore(deps): update dependencies\n\n- go.sum (+2 -1)"; message != want {
+		t.Errorf("Expected %q, got %q", want, message)
+	}
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultGitHubCatalogURL = "https://models.github.ai/catalog/models"
+	azureDeploymentsVersion = "2022-12-01"
+)
+
+// Model describes a model offered by a provider
+type Model struct {
+	ID            string
+	ContextWindow int
+}
+
+// ModelLister is implemented by providers that can enumerate their available models
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]Model, error)
+}
+
+// AuthChecker is implemented by providers whose model listing does not verify credentials
+type AuthChecker interface {
+	CheckAuth(ctx context.Context) error
+}
+
+// knownContextWindows maps model ID prefixes to their context window size in tokens,
+// used when a provider's listing endpoint does not report it
+var knownContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4-mini", 200000},
+	{"claude-", 200000},
+}
+
+// contextWindow returns the known context window of a model, or 0 if it is unknown
+func contextWindow(id string) int {
+	for _, known := range knownContextWindows {
+		if strings.HasPrefix(id, known.prefix) {
+			return known.tokens
+		}
+	}
+	return 0
+}
+
+// sortModels orders models by ID and fills in known context windows
+func sortModels(models []Model) []Model {
+	for i := range models {
+		if models[i].ContextWindow == 0 {
+			models[i].ContextWindow = contextWindow(models[i].ID)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models
+}
+
+// ListModels lists the models available to the OpenAI API key. A base URL other than the
+// OpenAI API may be an Ollama server, whose installed models are listed from /api/tags.
+func (p *OpenAIProvider) ListModels(ctx context.Context) ([]Model, error) {
+	if p.baseURL != defaultOpenAIBaseURL {
+		if models, ok := p.listOllamaModels(ctx); ok {
+			return models, nil
+		}
+	}
+
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.apiKey)
+	if err := getJSON(ctx, p.baseURL+"/v1/models", header, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list OpenAI models: %w", err)
+	}
+
+	models := make([]Model, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, Model{ID: m.ID})
+	}
+	return sortModels(models), nil
+}
+
+// listOllamaModels lists the models installed on an Ollama server, or returns false when the
+// server does not answer like one
+func (p *OpenAIProvider) listOllamaModels(ctx context.Context) ([]Model, bool) {
+	var resp struct {
+		Models *[]struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(ctx, strings.TrimSuffix(p.baseURL, "/")+"/api/tags", http.Header{}, &resp); err != nil || resp.Models == nil {
+		debugf("Not an Ollama server, listing models from /v1/models: %v", err)
+		return nil, false
+	}
+
+	models := make([]Model, 0, len(*resp.Models))
+	for _, m := range *resp.Models {
+		models = append(models, Model{ID: m.Name})
+	}
+	return sortModels(models), true
+}
+
+// ListModels lists the models available to the Anthropic API key
+func (p *ClaudeProvider) ListModels(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Data []struct {
+			ID             string `json:"id"`
+			MaxInputTokens int    `json:"max_input_tokens"`
+		} `json:"data"`
+	}
+	header := http.Header{}
+	header.Set("x-api-key", p.apiKey)
+	header.Set("anthropic-version", "2023-06-01")
+	if err := getJSON(ctx, p.baseURL+"/v1/models?limit=1000", header, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list Claude models: %w", err)
+	}
+
+	models := make([]Model, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, Model{ID: m.ID, ContextWindow: m.MaxInputTokens})
+	}
+	return sortModels(models), nil
+}
+
+// ListModels lists the models in the GitHub Models catalog
+func (p *GitHubProvider) ListModels(ctx context.Context) ([]Model, error) {
+	var resp []struct {
+		ID     string `json:"id"`
+		Limits struct {
+			MaxInputTokens int `json:"max_input_tokens"`
+		} `json:"limits"`
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.token)
+	if err := getJSON(ctx, p.catalogURL, header, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list GitHub Models catalog: %w", err)
+	}
+
+	// Catalog IDs carry a publisher prefix ("openai/gpt-4o") that the inference endpoint does not use
+	models := make([]Model, 0, len(resp))
+	for _, m := range resp {
+		id := m.ID[strings.LastIndex(m.ID, "/")+1:]
+		models = append(models, Model{ID: id, ContextWindow: m.Limits.MaxInputTokens})
+	}
+	return sortModels(models), nil
+}
+
+// CheckAuth sends a minimal chat request, since the catalog is public and accepts any token
+func (p *GitHubProvider) CheckAuth(ctx context.Context) error {
+	req := openAIRequest{
+		Model:    p.model,
+		Messages: []openAIMessage{{Role: "user", Content: "ping"}},
+	}
+	req.applyParams(p.params.WithMaxTokens(16), p.model)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.token)
+	if _, err := postJSON(ctx, p.baseURL+"/chat/completions", header, req); err != nil {
+		return fmt.Errorf("failed to authenticate with GitHub Models: %w", err)
+	}
+	return nil
+}
+
+// ListModels lists the deployments of the Azure OpenAI resource
+func (p *AzureOpenAIProvider) ListModels(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Model string `json:"model"`
+		} `json:"data"`
+	}
+	endpoint := strings.TrimSuffix(p.endpoint, "/")
+	url := fmt.Sprintf("%s/openai/deployments?api-version=%s", endpoint, azureDeploymentsVersion)
+	header := http.Header{}
+	if err := p.setAuthHeader(ctx, header); err != nil {
+		return nil, err
+	}
+	if err := getJSON(ctx, url, header, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list Azure OpenAI deployments: %w", err)
+	}
+
+	models := make([]Model, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		models = append(models, Model{ID: d.ID, ContextWindow: contextWindow(d.Model)})
+	}
+	return sortModels(models), nil
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Unexpected Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o"},{"id":"custom-model"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "")
+	provider.baseURL = server.URL
+
+	models, err := provider.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(models))
+	}
+	if models[0].ID != "custom-model" || models[0].ContextWindow != 0 {
+		t.Errorf("Unexpected first model: %+v", models[0])
+	}
+	if models[1].ID != "gpt-4o" || models[1].ContextWindow != 128000 {
+		t.Errorf("Unexpected second model: %+v", models[1])
+	}
+}
+
+func TestOpenAIProvider_ListModels_Ollama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"qwen2.5-coder:7b","size":4683087332},{"name":"llama3.1:8b","size":4920753328}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("", "")
+	provider.baseURL = server.URL
+
+	models, err := provider.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "llama3.1:8b" || models[1].ID != "qwen2.5-coder:7b" {
+		t.Errorf("Unexpected models: %+v", models)
+	}
+}
+
+func TestGitHubProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/catalog/models" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`[{"id":"openai/gpt-4o-mini","limits":{"max_input_tokens":131072}}]`))
+	}))
+	defer server.Close()
+
+	provider := NewGitHubProvider("test-token", "")
+	provider.catalogURL = server.URL + "/catalog/models"
+
+	models, err := provider.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	if len(models) != 1 || models[0].ID != "gpt-4o-mini" || models[0].ContextWindow != 131072 {
+		t.Errorf("Unexpected models: %+v", models)
+	}
+}
+
+func TestGitHubProvider_CheckAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"unauthorized","message":"Bad credentials"}}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"pong"},"finish_reason":"length"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid token", token: "good-token"},
+		{name: "rejected token", token: "bad-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewGitHubProvider(tt.token, "gpt-4o-mini")
+			provider.baseURL = server.URL
+
+			err := provider.CheckAuth(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaudeProvider_ListModels_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider("bad-key", "")
+	provider.baseURL = server.URL
+
+	if _, err := provider.ListModels(context.Background()); err == nil {
+		t.Error("Expected error for unauthorized response, got nil")
+	}
+}
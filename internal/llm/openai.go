@@ -7,25 +7,71 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
 )
 
+const defaultOpenAIBaseURL = "https://api.openai.com"
+
 // OpenAIProvider implements the Provider interface for OpenAI
 type OpenAIProvider struct {
-	apiKey string
-	model  string
+	apiKey     string
+	model      string
+	baseURL    string
+	params     config.GenerationConfig
+	apiOptions config.OpenAIAPIOptions
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
 func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
 	return &OpenAIProvider{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: defaultOpenAIBaseURL,
 	}
 }
 
 type openAIRequest struct {
-	Model    string          `json:"model"`
-	Messages []openAIMessage `json:"messages"`
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	Temperature         *float64        `json:"temperature,omitempty"`
+	TopP                *float64        `json:"top_p,omitempty"`
+	MaxTokens           int             `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+	Seed                *int64          `json:"seed,omitempty"`
+	Stop                []string        `json:"stop,omitempty"`
+	ReasoningEffort     string          `json:"reasoning_effort,omitempty"`
+}
+
+// applyParams maps the generation parameters onto a chat completions request.
+// Reasoning models take max_completion_tokens and reject sampling parameters.
+func (r *openAIRequest) applyParams(params config.GenerationConfig, model string) {
+	r.Seed = params.Seed
+	r.Stop = params.Stop
+
+	if !isReasoningModel(model) {
+		r.Temperature = params.Temperature
+		r.TopP = params.TopP
+		r.MaxTokens = params.MaxTokens
+		return
+	}
+
+	r.MaxCompletionTokens = params.MaxTokens
+	if params.Temperature != nil || params.TopP != nil {
+		debugf("%s: temperature and top_p are not supported by reasoning models, dropping them", model)
+	}
+}
+
+// applyReasoningEffort sets the reasoning effort, which only reasoning models accept
+func (r *openAIRequest) applyReasoningEffort(effort, model string) {
+	if effort == "" {
+		return
+	}
+	if !isReasoningModel(model) {
+		debugf("%s: reasoning_effort is only supported by reasoning models, dropping it", model)
+		return
+	}
+	r.ReasoningEffort = effort
 }
 
 type openAIMessage struct {
@@ -33,10 +79,39 @@ type openAIMessage struct {
 	Content string `json:"content"`
 }
 
+// logUsage reports token usage, including prompt tokens served from the provider's cache
+func (r *openAIResponse) logUsage(ctx context.Context, service string) {
+	if r.Usage == nil {
+		return
+	}
+	addUsage(ctx, r.Usage.PromptTokens, r.Usage.CompletionTokens)
+	debugf("%s: prompt tokens %d (cached %d), completion tokens %d",
+		service, r.Usage.PromptTokens, r.Usage.PromptTokensDetails.CachedTokens, r.Usage.CompletionTokens)
+}
+
+// openAIMessages puts the stable instructions in a system message ahead of the staged changes,
+// so OpenAI's automatic prompt caching can reuse the prefix
+func (p promptParts) openAIMessages() []openAIMessage {
+	return []openAIMessage{
+		{Role: "system", Content: p.system},
+		{Role: "user", Content: p.user},
+	}
+}
+
+type openAIChoice struct {
+	Message      openAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
 type openAIResponse struct {
-	Choices []struct {
-		Message openAIMessage `json:"message"`
-	} `json:"choices"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *struct {
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
@@ -44,24 +119,34 @@ type openAIResponse struct {
 
 // GenerateCommitMessage generates a commit message using OpenAI
 func (p *OpenAIProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	parts := buildPromptParts(diff, guidelines, instructionFrom(ctx))
+	return generateUntilComplete(p.params.MaxTokens, func(maxTokens int) (string, bool, error) {
+		if p.apiOptions.API == "responses" {
+			return p.respond(ctx, parts, maxTokens)
+		}
+		return p.complete(ctx, parts, maxTokens)
+	})
+}
+
+// complete sends a single chat completions request and reports whether the output was truncated
+func (p *OpenAIProvider) complete(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
 	req := openAIRequest{
-		Model: p.model,
-		Messages: []openAIMessage{
-			{
-				Role:    "user",
-				Content: buildPromptWithGuidelines(diff, guidelines),
-			},
-		},
+		Model:    p.model,
+		Messages: parts.openAIMessages(),
 	}
 
+	req.applyParams(p.params.WithMaxTokens(maxTokens), p.model)
+	req.applyReasoningEffort(p.apiOptions.ReasoningEffort, p.model)
+
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", false, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	addSentRequest(ctx, body)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/chat/completions", bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -70,27 +155,45 @@ func (p *OpenAIProvider) GenerateCommitMessage(ctx context.Context, diff string,
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", false, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", false, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var openAIResp openAIResponse
 	if err := json.Unmarshal(respBody, &openAIResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", false, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if openAIResp.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
+		return "", false, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from OpenAI")
+		return "", false, fmt.Errorf("no choices returned from OpenAI")
+	}
+
+	openAIResp.logUsage(ctx, "openai")
+
+	choice := openAIResp.Choices[0]
+	return choice.Message.Content, choice.FinishReason == "length", nil
+}
+
+// respond sends a single Responses API request and reports whether the output was truncated
+func (p *OpenAIProvider) respond(ctx context.Context, parts promptParts, maxTokens int) (string, bool, error) {
+	req := newResponsesRequest(p.model, parts, p.params.WithMaxTokens(maxTokens), p.apiOptions)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.apiKey)
+
+	respBody, err := postJSON(ctx, p.baseURL+"/v1/responses", header, req)
+	if err != nil {
+		return "", false, err
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	return parseResponsesResponse(ctx, respBody, "OpenAI", p.apiOptions.StructuredOutput)
 }
@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/redact"
+)
+
+// RecordingProvider wraps a provider and stores every request/response pair in a cassette
+type RecordingProvider struct {
+	provider Provider
+	path     string
+	name     string
+	model    string
+	redactor *redact.Redactor
+}
+
+// NewRecordingProvider creates the provider described by cfg and records its interactions to path
+func NewRecordingProvider(cfg *config.Config, path string) (*RecordingProvider, error) {
+	if cfg.Provider == "replay" {
+		return nil, fmt.Errorf("cannot record the replay provider")
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := cassetteRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecordingProvider{
+		provider: provider,
+		path:     path,
+		name:     cfg.Provider,
+		model:    providerModel(cfg),
+		redactor: redactor,
+	}, nil
+}
+
+// GenerateCommitMessage generates a commit message with the wrapped provider and records the result
+func (p *RecordingProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	message, genErr := p.provider.GenerateCommitMessage(ctx, diff, guidelines)
+
+	req := CassetteRequest{
+		Provider:    p.name,
+		Model:       p.model,
+		Diff:        redactText(p.redactor, diff),
+		Guidelines:  redactText(p.redactor, guidelines),
+		Instruction: instructionFrom(ctx),
+	}
+	interaction := Interaction{
+		Hash:    requestHash(req.Diff, req.Guidelines, req.Instruction),
+		Request: req,
+	}
+	if genErr != nil {
+		interaction.Response.Error = redactText(p.redactor, genErr.Error())
+	} else {
+		interaction.Response.Message = message
+	}
+
+	cassette, err := loadOrCreateCassette(p.path)
+	if err != nil {
+		return "", err
+	}
+	cassette.Put(interaction)
+	if err := cassette.Save(p.path); err != nil {
+		return "", err
+	}
+
+	return message, genErr
+}
+
+// ReplayProvider implements the Provider interface by serving responses from a cassette
+type ReplayProvider struct {
+	path     string
+	cassette *Cassette
+	redactor *redact.Redactor
+}
+
+// NewReplayProvider creates a new replay provider from a cassette file. Requests are redacted
+// with redactor before they are looked up, as they were when recorded.
+func NewReplayProvider(path string, redactor *redact.Redactor) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayProvider{
+		path:     path,
+		cassette: cassette,
+		redactor: redactor,
+	}, nil
+}
+
+// GenerateCommitMessage returns the recorded commit message for the request
+func (p *ReplayProvider) GenerateCommitMessage(ctx context.Context, diff string, guidelines string) (string, error) {
+	diff = redactText(p.redactor, diff)
+	guidelines = redactText(p.redactor, guidelines)
+
+	instruction := instructionFrom(ctx)
+
+	interaction, ok := p.cassette.Find(requestHash(diff, guidelines, instruction))
+	if !ok {
+		return "", p.mismatchError(diff, guidelines, instruction)
+	}
+
+	if interaction.Response.Error != "" {
+		return "", errors.New(interaction.Response.Error)
+	}
+
+	return interaction.Response.Message, nil
+}
+
+// mismatchError describes how the request differs from the closest recorded one
+func (p *ReplayProvider) mismatchError(diff, guidelines, instruction string) error {
+	if len(p.cassette.Interactions) == 0 {
+		return fmt.Errorf("cassette %s contains no recorded interactions", p.path)
+	}
+
+	var closest *Interaction
+	var closestDiff string
+	for i := range p.cassette.Interactions {
+		candidate := &p.cassette.Interactions[i]
+		var changes strings.Builder
+		if d := diffLines(candidate.Request.Diff, diff); d != "" {
+			changes.WriteString("diff:\n" + d)
+		}
+		if d := diffLines(candidate.Request.Guidelines, guidelines); d != "" {
+			changes.WriteString("guidelines:\n" + d)
+		}
+		if d := diffLines(candidate.Request.Instruction, instruction); d != "" {
+			changes.WriteString("instruction:\n" + d)
+		}
+		if closest == nil || changes.Len() < len(closestDiff) {
+			closest = candidate
+			closestDiff = changes.String()
+		}
+	}
+
+	return fmt.Errorf("no recorded interaction in %s matches request %s; closest recording %s differs:\n%s",
+		p.path, shortHash(requestHash(diff, guidelines, instruction)), shortHash(closest.Hash), strings.TrimSuffix(closestDiff, "\n"))
+}
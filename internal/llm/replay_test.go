@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func newOpenAITestServer(t *testing.T, message string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]string{"role": "assistant", "content": message}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := newOpenAITestServer(t, "feat: add new feature")
+	cassettePath := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	cfg := &config.Config{
+		Provider: "openai",
+		OpenAI: &config.OpenAIConfig{
+			APIKey:  "sk-test-secret-key-0123456789",
+			Model:   "gpt-4",
+			BaseURL: server.URL,
+		},
+	}
+
+	recorder, err := NewRecordingProvider(cfg, cassettePath)
+	if err != nil {
+		t.Fatalf("NewRecordingProvider failed: %v", err)
+	}
+
+	diff := "diff --git a/app.env b/app.env\n+TOKEN=sk-test-secret-key-0123456789"
+	message, err := recorder.GenerateCommitMessage(context.Background(), diff, "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if message != "feat: add new feature" {
+		t.Errorf("Unexpected message: %s", message)
+	}
+
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	if strings.Contains(string(data), "sk-test-secret-key") {
+		t.Error("Cassette should not contain the API key")
+	}
+
+	replay, err := NewProvider(&config.Config{
+		Provider: "replay",
+		Replay:   &config.ReplayConfig{Cassette: cassettePath},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	message, err = replay.GenerateCommitMessage(context.Background(), diff, "")
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if message != "feat: add new feature" {
+		t.Errorf("Unexpected replayed message: %s", message)
+	}
+}
+
+func TestRecordAndReplay_Instruction(t *testing.T) {
+	instruction := "Only mention names that appear in the diff. These do not: Cache"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		system := req.Messages[0].Content
+		message := "feat: add Cache"
+		if strings.Contains(system, instruction) {
+			if !strings.Contains(system, "</guidelines>\n\n"+instruction) {
+				t.Errorf("Expected the instruction after the guidelines block, got %q", system)
+			}
+			message = "feat: add store"
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": message}}},
+		})
+	}))
+	defer server.Close()
+
+	cassettePath := filepath.Join(t.TempDir(), "cassette.yaml")
+	cfg := &config.Config{
+		Provider: "openai",
+		OpenAI:   &config.OpenAIConfig{APIKey: "sk-test", Model: "gpt-4", BaseURL: server.URL},
+	}
+	recorder, err := NewRecordingProvider(cfg, cassettePath)
+	if err != nil {
+		t.Fatalf("NewRecordingProvider failed: %v", err)
+	}
+
+	// The retry with the instruction is recorded apart from the first request and replayed as such
+	diff := "diff --git a/store.go b/store.go\n+type Store struct{}"
+	ctx := context.Background()
+	for i, name := range []string{"record", "replay"} {
+		var provider Provider = recorder
+		if i == 1 {
+			if provider, err = NewProvider(&config.Config{Provider: "replay", Replay: &config.ReplayConfig{Cassette: cassettePath}}); err != nil {
+				t.Fatalf("NewProvider failed: %v", err)
+			}
+		}
+
+		first, err := provider.GenerateCommitMessage(ctx, diff, "Use past tense")
+		if err != nil {
+			t.Fatalf("GenerateCommitMessage failed: %v", err)
+		}
+		retry, err := provider.GenerateCommitMessage(WithInstruction(ctx, instruction), diff, "Use past tense")
+		if err != nil {
+			t.Fatalf("GenerateCommitMessage failed: %v", err)
+		}
+		if first != "feat: add Cache" || retry != "feat: add store" {
+			t.Errorf("%s: unexpected messages %q and %q", name, first, retry)
+		}
+	}
+}
+
+func TestRecordAndReplay_RedactionPatterns(t *testing.T) {
+	server := newOpenAITestServer(t, "chore: rotate the staging token")
+	cassettePath := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	redaction := &config.RedactionConfig{Patterns: []string{`ACME-[0-9]{6}`}}
+	cfg := &config.Config{
+		Provider:  "openai",
+		OpenAI:    &config.OpenAIConfig{APIKey: "test-key", Model: "gpt-4", BaseURL: server.URL},
+		Redaction: redaction,
+	}
+	recorder, err := NewRecordingProvider(cfg, cassettePath)
+	if err != nil {
+		t.Fatalf("NewRecordingProvider failed: %v", err)
+	}
+
+	diff := "diff --git a/deploy.sh b/deploy.sh\n+export TOKEN=ACME-123456"
+	if _, err := recorder.GenerateCommitMessage(context.Background(), diff, ""); err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	if strings.Contains(string(data), "ACME-123456") {
+		t.Error("Cassette should not contain values matching the redaction patterns")
+	}
+
+	replay, err := NewProvider(&config.Config{
+		Provider:  "replay",
+		Replay:    &config.ReplayConfig{Cassette: cassettePath},
+		Redaction: redaction,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	message, err := replay.GenerateCommitMessage(context.Background(), diff, "")
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if message != "chore: rotate the staging token" {
+		t.Errorf("Unexpected replayed message: %s", message)
+	}
+}
+
+func TestReplayProvider_Mismatch(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorded := "diff --git a/main.go b/main.go\n+func main() {}"
+
+	cassette := &Cassette{}
+	cassette.Put(Interaction{
+		Hash:     requestHash(recorded, "", ""),
+		Request:  CassetteRequest{Diff: recorded},
+		Response: CassetteResponse{Message: "feat: add main"},
+	})
+	if err := cassette.Save(cassettePath); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+
+	redactor, err := cassetteRedactor(&config.Config{})
+	if err != nil {
+		t.Fatalf("cassetteRedactor failed: %v", err)
+	}
+	provider, err := NewReplayProvider(cassettePath, redactor)
+	if err != nil {
+		t.Fatalf("NewReplayProvider failed: %v", err)
+	}
+
+	_, err = provider.GenerateCommitMessage(context.Background(), "diff --git a/main.go b/main.go\n+func run() {}", "")
+	if err == nil {
+		t.Fatal("Expected error for unrecorded request, got nil")
+	}
+
+	for _, want := range []string{"- +func main() {}", "+ +func run() {}"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestNewRecordingProvider_Replay(t *testing.T) {
+	cfg := &config.Config{
+		Provider: "replay",
+		Replay:   &config.ReplayConfig{Cassette: "cassette.yaml"},
+	}
+
+	if _, err := NewRecordingProvider(cfg, "out.yaml"); err == nil {
+		t.Error("Expected error when recording the replay provider, got nil")
+	}
+}
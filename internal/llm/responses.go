@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+type responsesRequest struct {
+	Model           string              `json:"model,omitempty"`
+	Instructions    string              `json:"instructions,omitempty"`
+	Input           string              `json:"input"`
+	Temperature     *float64            `json:"temperature,omitempty"`
+	TopP            *float64            `json:"top_p,omitempty"`
+	MaxOutputTokens int                 `json:"max_output_tokens,omitempty"`
+	Reasoning       *responsesReasoning `json:"reasoning,omitempty"`
+	Text            *responsesText      `json:"text,omitempty"`
+}
+
+type responsesReasoning struct {
+	Effort string `json:"effort"`
+}
+
+type responsesText struct {
+	Format responsesFormat `json:"format"`
+}
+
+type responsesFormat struct {
+	Type   string         `json:"type"`
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	Strict bool           `json:"strict"`
+}
+
+type responsesResponse struct {
+	Status            string `json:"status"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details,omitempty"`
+	Output []struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// commitMessageSchema is the JSON schema requested when structured output is enabled
+var commitMessageSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"subject": map[string]any{
+			"type":        "string",
+			"description": "Conventional commit subject line",
+		},
+		"body": map[string]any{
+			"type":        "string",
+			"description": "Optional commit body, or an empty string",
+		},
+	},
+	"required":             []string{"subject", "body"},
+	"additionalProperties": false,
+}
+
+// newResponsesRequest builds a Responses API request, mapping the generation parameters and API options
+func newResponsesRequest(model string, parts promptParts, params config.GenerationConfig, opts config.OpenAIAPIOptions) responsesRequest {
+	req := responsesRequest{
+		Model:           model,
+		Instructions:    parts.system,
+		Input:           parts.user,
+		MaxOutputTokens: params.MaxTokens,
+	}
+
+	if params.Seed != nil || len(params.Stop) > 0 {
+		debugf("%s: seed and stop are not supported by the Responses API, dropping them", model)
+	}
+
+	if isReasoningModel(model) {
+		if params.Temperature != nil || params.TopP != nil {
+			debugf("%s: temperature and top_p are not supported by reasoning models, dropping them", model)
+		}
+		if opts.ReasoningEffort != "" {
+			req.Reasoning = &responsesReasoning{Effort: opts.ReasoningEffort}
+		}
+	} else {
+		req.Temperature = params.Temperature
+		req.TopP = params.TopP
+		if opts.ReasoningEffort != "" {
+			debugf("%s: reasoning effort is only supported by reasoning models, dropping it", model)
+		}
+	}
+
+	if opts.StructuredOutput {
+		req.Text = &responsesText{Format: responsesFormat{
+			Type:   "json_schema",
+			Name:   "commit_message",
+			Schema: commitMessageSchema,
+			Strict: true,
+		}}
+	}
+
+	return req
+}
+
+// parseResponsesResponse extracts the commit message from a Responses API response
+// and reports whether it was cut off at the output token limit
+func parseResponsesResponse(ctx context.Context, body []byte, service string, structured bool) (string, bool, error) {
+	var resp responsesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.Error != nil {
+		return "", false, fmt.Errorf("%s API error: %s", service, resp.Error.Message)
+	}
+
+	if resp.Usage != nil {
+		addUsage(ctx, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	}
+
+	if resp.Status == "incomplete" && resp.IncompleteDetails != nil && resp.IncompleteDetails.Reason == "max_output_tokens" {
+		return "", true, nil
+	}
+
+	var text strings.Builder
+	for _, item := range resp.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, content := range item.Content {
+			if content.Type == "output_text" {
+				text.WriteString(content.Text)
+			}
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", false, fmt.Errorf("no output returned from %s", service)
+	}
+
+	if !structured {
+		return text.String(), false, nil
+	}
+
+	var message struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(text.String()), &message); err != nil {
+		return "", false, fmt.Errorf("failed to parse structured output: %w", err)
+	}
+
+	if body := strings.TrimSpace(message.Body); body != "" {
+		return message.Subject + "\n\n" + body, false, nil
+	}
+	return message.Subject, false, nil
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+)
+
+func TestOpenAIProvider_ResponsesAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/responses" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+
+		var req responsesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Reasoning == nil || req.Reasoning.Effort != "low" {
+			t.Errorf("Unexpected reasoning settings: %+v", req.Reasoning)
+		}
+		if req.Text == nil || req.Text.Format.Type != "json_schema" {
+			t.Errorf("Expected structured output format, got %+v", req.Text)
+		}
+
+		w.Write([]byte(`{"status":"completed","output":[
+			{"type":"reasoning","summary":[]},
+			{"type":"message","content":[{"type":"output_text","text":"{\"subject\":\"feat: add parser\",\"body\":\"Parse config files.\"}"}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&config.Config{
+		Provider: "openai",
+		OpenAI: &config.OpenAIConfig{
+			APIKey:  "test-key",
+			Model:   "o4-mini",
+			BaseURL: server.URL,
+			OpenAIAPIOptions: config.OpenAIAPIOptions{
+				API:              "responses",
+				ReasoningEffort:  "low",
+				StructuredOutput: true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if message != "feat: add parser\n\nParse config files." {
+		t.Errorf("Unexpected message: %q", message)
+	}
+}
+
+func TestNewResponsesRequest_NonReasoningModel(t *testing.T) {
+	temperature := 0.2
+	opts := config.OpenAIAPIOptions{API: "responses", ReasoningEffort: "low"}
+	req := newResponsesRequest("gpt-4o", promptParts{}, config.GenerationConfig{Temperature: &temperature}, opts)
+
+	if req.Reasoning != nil {
+		t.Errorf("Expected no reasoning settings for gpt-4o, got %+v", req.Reasoning)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("Expected the temperature to be kept, got %v", req.Temperature)
+	}
+}
+
+func TestOpenAIProvider_ResponsesAPIErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `upstream overloaded`, http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&config.Config{
+		Provider: "openai",
+		OpenAI: &config.OpenAIConfig{
+			APIKey:           "test-key",
+			Model:            "gpt-4o",
+			BaseURL:          server.URL,
+			OpenAIAPIOptions: config.OpenAIAPIOptions{API: "responses"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	_, err = provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "upstream overloaded") {
+		t.Errorf("Expected an error with the status and body, got %v", err)
+	}
+}
+
+func TestParseResponsesResponse_Incomplete(t *testing.T) {
+	body := []byte(`{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"output":[]}`)
+
+	_, truncated, err := parseResponsesResponse(context.Background(), body, "OpenAI", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !truncated {
+		t.Error("Expected incomplete response to be reported as truncated")
+	}
+}
+
+func TestAzureOpenAIProvider_ResponsesAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/responses" || r.URL.Query().Get("api-version") != azureResponsesAPIVersion {
+			t.Errorf("Unexpected URL: %s", r.URL)
+		}
+
+		var req responsesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "my-deployment" {
+			t.Errorf("Expected deployment as model, got %s", req.Model)
+		}
+
+		w.Write([]byte(`{"status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"docs: update readme"}]}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&config.Config{
+		Provider: "azure",
+		Azure: &config.AzureOpenAIConfig{
+			Endpoint:         server.URL,
+			APIKey:           "test-key",
+			Deployment:       "my-deployment",
+			OpenAIAPIOptions: config.OpenAIAPIOptions{API: "responses"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	message, err := provider.GenerateCommitMessage(context.Background(), "diff", "")
+	if err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if message != "docs: update readme" {
+		t.Errorf("Unexpected message: %q", message)
+	}
+}
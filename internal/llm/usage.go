@@ -0,0 +1,24 @@
+package llm
+
+import "context"
+
+// Usage counts the tokens reported by providers for their requests
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context in which providers add the tokens of each request to u
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// addUsage adds the tokens of a request to the Usage in the context, if any
+func addUsage(ctx context.Context, prompt, completion int) {
+	if u, ok := ctx.Value(usageKey{}).(*Usage); ok {
+		u.PromptTokens += prompt
+		u.CompletionTokens += completion
+	}
+}
@@ -0,0 +1,405 @@
+package mechanical
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/redact"
+	"github.com/algernon-coop/git-auto-commit/internal/whitespace"
+)
+
+// DefaultMinFiles is the number of files a mechanical change must span to be summarised
+const DefaultMinFiles = 5
+
+// SummaryTitle is the first line of the summary
+const SummaryTitle = "Mechanical changes summarised instead of shown in full:"
+
+// maxListedFiles is the number of paths listed for each pattern in the summary
+const maxListedFiles = 10
+
+// Pattern is a mechanical change repeated across several files
+type Pattern struct {
+	// Kind is "rename" for an identifier substitution, "replace" for any other repeated
+	// substitution, "move" for renames without content changes and "format" for
+	// whitespace and import order changes
+	Kind string
+	// Old and New are the substituted tokens of rename and replace patterns
+	Old   string
+	New   string
+	Files []git.FileDiff
+}
+
+func (p Pattern) String() string {
+	where := fmt.Sprintf("across %d files", len(p.Files))
+	if len(p.Files) == 1 {
+		where = "in " + p.Files[0].Path
+	}
+
+	switch p.Kind {
+	case "rename":
+		return fmt.Sprintf("rename %s to %s %s", p.Old, p.New, where)
+	case "replace":
+		return fmt.Sprintf("replace `%s` with `%s` %s", p.Old, p.New, where)
+	case "format":
+		return "reformat whitespace and import order " + where
+	}
+	return p.moveString()
+}
+
+// moveString describes a move pattern by the directory the files moved between, if they share one
+func (p Pattern) moveString() string {
+	if len(p.Files) == 1 {
+		return fmt.Sprintf("move %s to %s", p.Files[0].OldPath, p.Files[0].Path)
+	}
+
+	oldDir, newDir := path.Dir(p.Files[0].OldPath), path.Dir(p.Files[0].Path)
+	for _, f := range p.Files {
+		if path.Dir(f.OldPath) != oldDir || path.Dir(f.Path) != newDir || path.Base(f.OldPath) != path.Base(f.Path) {
+			return fmt.Sprintf("move or rename %d files without changing their content", len(p.Files))
+		}
+	}
+	return fmt.Sprintf("move %d files from %s/ to %s/", len(p.Files), oldDir, newDir)
+}
+
+// substitution is a token replaced by another on a changed line
+type substitution struct {
+	old, new string
+}
+
+// Detect groups the files whose changes are mechanical and returns the patterns spanning
+// at least minFiles files, largest first. Files with any other change are left out, and so
+// are files that the redactor, if not nil, withholds or finds a secret in: the summary
+// repeats the substituted tokens, so those files stay in the diff to be redacted there.
+func Detect(diff *git.Diff, minFiles int, redactor *redact.Redactor) []Pattern {
+	type key struct{ kind, old, new string }
+	var keys []key
+	groups := map[key][]git.FileDiff{}
+	for _, f := range diff.Files {
+		if redactor != nil && sensitive(redactor, f) {
+			continue
+		}
+		if p, ok := classifyFile(f); ok {
+			k := key{p.Kind, p.Old, p.New}
+			if groups[k] == nil {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], f)
+		}
+	}
+
+	var patterns []Pattern
+	for _, k := range keys {
+		if files := groups[k]; len(files) >= max(1, minFiles) {
+			patterns = append(patterns, Pattern{Kind: k.kind, Old: k.old, New: k.new, Files: files})
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return len(patterns[i].Files) > len(patterns[j].Files)
+	})
+	return patterns
+}
+
+// sensitive reports whether a file is on the redaction denylist or has a secret on a changed line
+func sensitive(r *redact.Redactor, f git.FileDiff) bool {
+	if r.Denied(f.Path) || (f.OldPath != "" && r.Denied(f.OldPath)) {
+		return true
+	}
+	var lines []string
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				lines = append(lines, line[1:])
+			}
+		}
+	}
+	return len(r.Scan(f.Path, lines)) > 0
+}
+
+// classifyFile returns the pattern a file belongs to, without its files, or false when
+// its change is not mechanical
+func classifyFile(f git.FileDiff) (Pattern, bool) {
+	if f.Binary || f.Submodule != nil || f.ModeChanged() {
+		return Pattern{}, false
+	}
+
+	switch f.Status {
+	case git.StatusRenamed:
+		if len(f.Hunks) == 0 {
+			return Pattern{Kind: "move"}, true
+		}
+	case git.StatusModified:
+	default:
+		return Pattern{}, false
+	}
+	if len(f.Hunks) == 0 {
+		return Pattern{}, false
+	}
+
+	subs := map[substitution]bool{}
+	for _, h := range f.Hunks {
+		for _, block := range changeBlocks(h.Lines) {
+			blockSubs, ok := compareBlock(f.Path, block.deleted, block.added)
+			if !ok {
+				return Pattern{}, false
+			}
+			for _, s := range blockSubs {
+				subs[s] = true
+			}
+		}
+	}
+
+	switch len(subs) {
+	case 0:
+		return Pattern{Kind: "format"}, true
+	case 1:
+		for s := range subs {
+			kind := "replace"
+			if isIdentifier(s.old) && isIdentifier(s.new) {
+				kind = "rename"
+			}
+			return Pattern{Kind: kind, Old: s.old, New: s.new}, true
+		}
+	}
+	// Several different substitutions in one file are a real edit, not a sweep
+	return Pattern{}, false
+}
+
+// block is a run of deleted lines followed by the added lines replacing them
+type block struct {
+	deleted, added []string
+}
+
+func changeBlocks(lines []string) []block {
+	var blocks []block
+	var current block
+	flush := func() {
+		if len(current.deleted) > 0 || len(current.added) > 0 {
+			blocks = append(blocks, current)
+		}
+		current = block{}
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			if len(current.added) > 0 {
+				flush()
+			}
+			current.deleted = append(current.deleted, line[1:])
+		case strings.HasPrefix(line, "+"):
+			current.added = append(current.added, line[1:])
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" belongs to the preceding line
+		default:
+			flush()
+		}
+	}
+	flush()
+	return blocks
+}
+
+// compareBlock returns the substitutions turning the deleted lines into the added ones,
+// or false when the lines differ in another way. Blocks that only reorder imports yield no
+// substitutions, and neither do lines that differ only in whitespace or are joined or
+// split, unless whitespace is significant in the file.
+func compareBlock(p string, deleted, added []string) ([]substitution, bool) {
+	significant := whitespace.Significant(p)
+	// gofmt joins and splits lines
+	if !significant && whitespace.Strip(strings.Join(deleted, "")) == whitespace.Strip(strings.Join(added, "")) {
+		return nil, true
+	}
+	// goimports and isort reorder imports
+	if importBlock(deleted) && importBlock(added) && sameLines(deleted, added, significant) {
+		return nil, true
+	}
+	if len(deleted) != len(added) {
+		return nil, false
+	}
+
+	var subs []substitution
+	for i := range deleted {
+		if significant && indentation(deleted[i]) != indentation(added[i]) {
+			return nil, false
+		}
+		s, ok := compareLine(deleted[i], added[i])
+		if !ok {
+			return nil, false
+		}
+		subs = append(subs, s...)
+	}
+	return subs, true
+}
+
+// importLine matches import statements, and the quoted paths of a Go import block
+var importLine = regexp.MustCompile(`^\s*(import\b|from\s+\S+\s+import\b|#include\b|#import\b|@import\b|using\s|use\s|(?:[\w.]+\s+)?"[^"]*"\s*(//.*)?$)`)
+
+// importBlock reports whether every non-blank line is an import
+func importBlock(lines []string) bool {
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !importLine.MatchString(line) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+}
+
+// compareLine returns the substitutions between two lines with the same tokens apart
+// from one token replaced by another, possibly several times
+func compareLine(oldLine, newLine string) ([]substitution, bool) {
+	oldTokens, newTokens := tokenize(oldLine), tokenize(newLine)
+	if len(oldTokens) != len(newTokens) {
+		return nil, false
+	}
+
+	var sub *substitution
+	for i := range oldTokens {
+		if oldTokens[i] == newTokens[i] {
+			continue
+		}
+		s := substitution{oldTokens[i], newTokens[i]}
+		if sub != nil && *sub != s {
+			return nil, false
+		}
+		sub = &s
+	}
+	if sub == nil {
+		return nil, true
+	}
+	return []substitution{*sub}, true
+}
+
+// tokenize splits a line into identifiers, numbers, quoted strings and single punctuation
+// characters, ignoring whitespace
+func tokenize(line string) []string {
+	var tokens []string
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '"' || r == '\'' || r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' && r != '`' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		return false
+	}
+	for _, r := range s {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameLines reports whether both sides hold the same lines in a different order, ignoring
+// blank lines and, unless it is significant, whitespace
+func sameLines(deleted, added []string, significant bool) bool {
+	normalize := func(lines []string) []string {
+		var out []string
+		for _, line := range lines {
+			s := line
+			if !significant {
+				s = whitespace.Strip(line)
+			}
+			if strings.TrimSpace(s) != "" {
+				out = append(out, s)
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	a, b := normalize(deleted), normalize(added)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths returns the paths of the files in the patterns, including the source of moves
+func Paths(patterns []Pattern) []string {
+	var paths []string
+	for _, p := range patterns {
+		for _, f := range p.Files {
+			paths = append(paths, f.Path)
+			if f.OldPath != "" && f.OldPath != f.Path {
+				paths = append(paths, f.OldPath)
+			}
+		}
+	}
+	return paths
+}
+
+// Summary describes the patterns and the files they cover for inclusion in the prompt
+func Summary(patterns []Pattern) string {
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(SummaryTitle)
+	for _, p := range patterns {
+		fmt.Fprintf(&b, "\n- %s", p)
+		if len(p.Files) == 1 {
+			continue
+		}
+		var paths []string
+		for i, f := range p.Files {
+			if i == maxListedFiles {
+				paths = append(paths, fmt.Sprintf("and %d more", len(p.Files)-i))
+				break
+			}
+			paths = append(paths, f.Path)
+		}
+		fmt.Fprintf(&b, "\n  %s", strings.Join(paths, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package pathmatch
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated path p matches any of the patterns.
+// A pattern matches the full path or the base name using path.Match syntax; a
+// pattern ending in "/" matches every file below a directory of that name.
+func Match(patterns []string, p string) bool {
+	base := path.Base(p)
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if strings.HasPrefix(p, dir+"/") || strings.Contains(p, "/"+dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if any pattern is malformed
+func Validate(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package pathmatch
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"go.sum", "go.sum", true},
+		{"go.sum", "tools/go.sum", true},
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"*.pb.go", "api/v1/service.go", false},
+		{"docs/*.png", "docs/logo.png", true},
+		{"docs/*.png", "docs/img/logo.png", false},
+		{"vendor/", "vendor/github.com/pkg/errors/errors.go", true},
+		{"vendor/", "third_party/vendor/lib.go", true},
+		{"vendor/", "vendor.go", false},
+		{"secrets/", "config/secrets.yaml", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match([]string{tt.pattern}, tt.path); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]string{"*.go", "vendor/"}); err != nil {
+		t.Errorf("Expected valid patterns, got %v", err)
+	}
+	if err := Validate([]string{"[a-"}); err == nil {
+		t.Error("Expected error for malformed pattern, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package privacy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/goapi"
+)
+
+// Privacy modes, from the least to the most restrictive
+const (
+	// Full sends the redacted diff
+	Full = "full"
+	// Metadata sends file paths, change statistics, Go symbol names and the branch name, but no code
+	Metadata = "metadata"
+)
+
+// GitConfigKey is the git configuration key that sets the privacy mode of a repository
+const GitConfigKey = "auto-commit.privacy"
+
+var strictness = map[string]int{"": 0, Full: 0, Metadata: 1}
+
+// Validate returns an error for an unknown privacy mode
+func Validate(mode string) error {
+	if _, ok := strictness[mode]; !ok {
+		return fmt.Errorf("unknown privacy mode: %s (expected %s or %s)", mode, Full, Metadata)
+	}
+	return nil
+}
+
+// Strictest returns the most restrictive of the given modes, so that a repository setting
+// can tighten the user configuration but never relax it
+func Strictest(modes ...string) (string, error) {
+	result := Full
+	for _, mode := range modes {
+		if err := Validate(mode); err != nil {
+			return "", err
+		}
+		if strictness[mode] > strictness[result] {
+			result = mode
+		}
+	}
+	return result, nil
+}
+
+// Prompt describes a change without any of its content: the branch, each file with its
+// status and line counts, and the names of the Go declarations that changed
+func Prompt(branch string, diff *git.Diff, decls []goapi.FileChanges) string {
+	var b strings.Builder
+	b.WriteString("Only metadata is shared for this change; the source code is not available.")
+	if branch != "" {
+		fmt.Fprintf(&b, "\n\nBranch: %s", branch)
+	}
+
+	b.WriteString("\n\nFiles changed:")
+	for _, f := range diff.Files {
+		fmt.Fprintf(&b, "\n- %s (%s)", f.Path, fileStats(f))
+	}
+
+	names := symbolNames(decls)
+	if len(names) > 0 {
+		b.WriteString("\n\nGo declarations changed:")
+		for _, name := range names {
+			fmt.Fprintf(&b, "\n- %s", name)
+		}
+	}
+	return b.String()
+}
+
+func fileStats(f git.FileDiff) string {
+	lines := fmt.Sprintf("+%d -%d lines", f.Added, f.Deleted)
+	if f.Binary {
+		lines = "binary"
+	}
+
+	switch f.Status {
+	case git.StatusRenamed, git.StatusCopied:
+		return fmt.Sprintf("%s from %s, %s", f.Status, f.OldPath, lines)
+	case git.StatusDeleted:
+		return string(f.Status)
+	}
+	return fmt.Sprintf("%s, %s", f.Status, lines)
+}
+
+// symbolNames lists the changed declarations by kind and name, leaving out signatures and
+// values since they are code
+func symbolNames(decls []goapi.FileChanges) []string {
+	var names []string
+	for _, f := range decls {
+		for _, c := range f.Changes {
+			names = append(names, fmt.Sprintf("%s %s %s in %s", c.Kind, c.Decl.Kind, c.Decl.Name, f.Path))
+		}
+	}
+	return names
+}
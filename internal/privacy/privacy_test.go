@@ -0,0 +1,72 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/goapi"
+)
+
+func TestStrictest(t *testing.T) {
+	tests := []struct {
+		name    string
+		modes   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", modes: []string{"", ""}, want: Full},
+		{name: "user setting", modes: []string{Metadata, ""}, want: Metadata},
+		{name: "repository tightens", modes: []string{Full, Metadata}, want: Metadata},
+		{name: "repository cannot relax", modes: []string{Metadata, Full}, want: Metadata},
+		{name: "unknown mode", modes: []string{"", "paths"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Strictest(tt.modes...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Strictest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Strictest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	diff := git.ParseDiff("diff --git a/store/store.go b/store/store.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/store/store.go\n" +
+		"+++ b/store/store.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package store\n" +
+		"-const secretSauce = \"v1\"\n" +
+		"+const secretSauce = \"v2\"\n" +
+		"diff --git a/old.txt b/new.txt\n" +
+		"similarity index 100%\n" +
+		"rename from old.txt\n" +
+		"rename to new.txt\n")
+	decls := []goapi.FileChanges{{Path: "store/store.go", Changes: []goapi.Change{
+		{Kind: "changed", Decl: goapi.Decl{Kind: "func", Name: "Store.Get", Signature: "func (s *Store) Get(key string) string"}},
+	}}}
+
+	prompt := Prompt("feature/cache", diff, decls)
+
+	for _, want := range []string{
+		"Branch: feature/cache",
+		"- store/store.go (modified, +1 -1 lines)",
+		"- new.txt (renamed from old.txt, +0 -0 lines)",
+		"- changed func Store.Get in store/store.go",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+	for _, leaked := range []string{"secretSauce", "key string", "package store"} {
+		if strings.Contains(prompt, leaked) {
+			t.Errorf("Expected the prompt to leave out code, found %q in:\n%s", leaked, prompt)
+		}
+	}
+}
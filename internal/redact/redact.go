@@ -0,0 +1,294 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+)
+
+// defaultEntropyThreshold is the Shannon entropy in bits per character above which a token
+// is treated as a secret; random base64 strings score close to 5, identifiers and words well below
+const defaultEntropyThreshold = 4.5
+
+// minEntropyTokenLength is the shortest token checked for high entropy
+const minEntropyTokenLength = 24
+
+// Finding describes a single redacted secret or withheld file
+type Finding struct {
+	Kind string
+	Path string
+}
+
+type rule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// defaultRules detects common credential formats
+var defaultRules = []rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"anthropic-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai-key", regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// defaultDenyPaths are files whose contents are never sent to a provider
+var defaultDenyPaths = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	".npmrc",
+	".pypirc",
+	".netrc",
+	"credentials.json",
+}
+
+// checksumFiles hold hashes by design, so the entropy check is skipped for them
+var checksumFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"poetry.lock":       true,
+	"Gemfile.lock":      true,
+	"composer.lock":     true,
+}
+
+var entropyTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+// Redactor removes secrets from diffs before they leave the machine
+type Redactor struct {
+	rules            []rule
+	denyPaths        []string
+	entropyThreshold float64
+}
+
+// New creates a Redactor with the default rules extended by the configuration
+func New(cfg *config.RedactionConfig) (*Redactor, error) {
+	r := &Redactor{
+		rules:            append([]rule{}, defaultRules...),
+		denyPaths:        append([]string{}, defaultDenyPaths...),
+		entropyThreshold: defaultEntropyThreshold,
+	}
+
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.rules = append(r.rules, rule{kind: "custom-pattern", re: re})
+	}
+
+	if err := pathmatch.Validate(cfg.DenyPaths); err != nil {
+		return nil, fmt.Errorf("invalid redaction deny path: %w", err)
+	}
+	r.denyPaths = append(r.denyPaths, cfg.DenyPaths...)
+
+	if cfg.EntropyThreshold != 0 {
+		r.entropyThreshold = cfg.EntropyThreshold
+	}
+
+	return r, nil
+}
+
+// AddValues redacts every occurrence of the given literal values, such as the credentials in
+// the configuration, ahead of the pattern rules
+func (r *Redactor) AddValues(kind string, values ...string) {
+	var rules []rule
+	for _, v := range values {
+		if v != "" {
+			rules = append(rules, rule{kind: kind, re: regexp.MustCompile(regexp.QuoteMeta(v))})
+		}
+	}
+	r.rules = append(rules, r.rules...)
+}
+
+// Redact replaces secrets in a unified diff with placeholders and withholds the contents of denied
+// paths. Text ahead of the first file, such as the summaries in a prompt, is checked as plain text.
+func (r *Redactor) Redact(diff string) (string, []Finding) {
+	var findings []Finding
+	var result strings.Builder
+
+	preamble := diff
+	if strings.HasPrefix(diff, "diff --git ") {
+		preamble = ""
+	} else if i := strings.Index(diff, "\ndiff --git "); i >= 0 {
+		preamble = diff[:i+1]
+	}
+	parsed := git.ParseDiff(diff[len(preamble):])
+	if len(parsed.Files) == 0 {
+		preamble = diff
+	}
+
+	if preamble != "" {
+		// There are no paths to deny outside the diff, so the text is checked as a whole
+		redacted, kinds := r.redactSection(preamble, true)
+		for _, kind := range kinds {
+			findings = append(findings, Finding{Kind: kind})
+		}
+		result.WriteString(redacted)
+	}
+
+	for i, file := range parsed.Files {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+
+		if r.Denied(file.Path) {
+			header, _, _ := strings.Cut(file.Raw, "\n")
+			result.WriteString(header)
+			result.WriteString("\n[contents withheld: path is on the redaction denylist]")
+			findings = append(findings, Finding{Kind: "denied-path", Path: file.Path})
+			continue
+		}
+
+		redacted, kinds := r.redactSection(file.Raw, !checksumFiles[path.Base(file.Path)])
+		for _, kind := range kinds {
+			findings = append(findings, Finding{Kind: kind, Path: file.Path})
+		}
+		result.WriteString(redacted)
+	}
+
+	return result.String(), findings
+}
+
+// Denied reports whether the contents of the file at p are withheld from providers
+func (r *Redactor) Denied(p string) bool {
+	return pathmatch.Match(r.denyPaths, p)
+}
+
+// Scan returns the secrets found in lines added to the file at p, without the diff's removed
+// and context lines, so that removing a secret is not reported as one
+func (r *Redactor) Scan(p string, lines []string) []Finding {
+	if len(lines) == 0 {
+		return nil
+	}
+	_, kinds := r.redactSection("+"+strings.Join(lines, "\n+"), !checksumFiles[path.Base(p)])
+
+	findings := make([]Finding, len(kinds))
+	for i, kind := range kinds {
+		findings[i] = Finding{Kind: kind, Path: p}
+	}
+	return findings
+}
+
+// redactSection applies the pattern rules and the entropy check to one file section
+func (r *Redactor) redactSection(section string, checkEntropy bool) (string, []string) {
+	var kinds []string
+
+	for _, rl := range r.rules {
+		section = rl.re.ReplaceAllStringFunc(section, func(string) string {
+			kinds = append(kinds, rl.kind)
+			return placeholder(rl.kind)
+		})
+	}
+
+	if !checkEntropy || r.entropyThreshold <= 0 {
+		return section, kinds
+	}
+
+	lines := strings.Split(section, "\n")
+	for i, line := range lines {
+		// Only changed lines carry content; headers hold hashes that are not secrets
+		if !isChangedLine(line) {
+			continue
+		}
+		lines[i] = entropyTokenPattern.ReplaceAllStringFunc(line, func(token string) string {
+			if !looksRandom(token, r.entropyThreshold) {
+				return token
+			}
+			kinds = append(kinds, "high-entropy-string")
+			return placeholder("high-entropy-string")
+		})
+	}
+
+	return strings.Join(lines, "\n"), kinds
+}
+
+// Summary formats findings for display, grouped by kind and path
+func Summary(findings []Finding) string {
+	counts := map[Finding]int{}
+	var order []Finding
+	for _, f := range findings {
+		if counts[f] == 0 {
+			order = append(order, f)
+		}
+		counts[f]++
+	}
+
+	var lines []string
+	for _, f := range order {
+		line := fmt.Sprintf("%s in %s", f.Kind, f.Path)
+		if f.Path == "" {
+			line = f.Kind
+		}
+		if counts[f] > 1 {
+			line += fmt.Sprintf(" (%d)", counts[f])
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func placeholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+func isChangedLine(line string) bool {
+	return (strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++")) ||
+		(strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"))
+}
+
+// looksRandom reports whether a token mixes letters and digits and has high Shannon entropy
+func looksRandom(token string, threshold float64) bool {
+	if len(token) < minEntropyTokenLength || strings.HasPrefix(token, "[REDACTED") {
+		return false
+	}
+	if !strings.ContainsAny(token, "0123456789") || strings.IndexFunc(token, isLetter) < 0 {
+		return false
+	}
+	return shannonEntropy(token) >= threshold
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	freq := map[rune]float64{}
+	for _, r := range s {
+		freq[r]++
+	}
+
+	var entropy float64
+	n := float64(len(s))
+	for _, count := range freq {
+		p := count / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,109 @@
+package safety
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+)
+
+// messagePath is the Path of issues found in the generated commit message
+const messagePath = "commit message"
+
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp|file)://[^\s<>"'` + "`" + `)\]]+|\bwww\.[a-z0-9-]+\.[a-z]{2,}[^\s<>"'` + "`" + `)\]]*`)
+
+// shellPatterns match commands that have no place in a commit message unless the change adds them
+var shellPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*\$ \S.*`),
+	regexp.MustCompile(`\b(?:curl|wget)\s+\S+`),
+	regexp.MustCompile(`\bsudo\s+\S+`),
+	regexp.MustCompile(`\brm\s+-[a-zA-Z]*[rf][a-zA-Z]*\s+\S+`),
+	regexp.MustCompile(`\|\s*(?:ba|z)?sh\b`),
+	regexp.MustCompile(`\b(?:ba|z)?sh\s+-c\s+\S+`),
+	regexp.MustCompile(`\bchmod\s+(?:\+x|[0-7]{3,4})\s+\S+`),
+	regexp.MustCompile(`\beval\s+["'$(]\S*`),
+	regexp.MustCompile(`\bbase64\s+(?:-d|--decode)\b`),
+	regexp.MustCompile(`(?i)\b(?:powershell|iex)\s+\S+`),
+}
+
+// commentPrefixes start a line comment in common languages and configuration formats
+var commentPrefixes = []string{"//", "#", "/*", "* ", "<!--", "-- ", ";", `"""`, "'''"}
+
+// trailingComments start a comment after code on the same line
+var trailingComments = []string{" //", "\t//", " #", "\t#", " /*", "<!--"}
+
+// proseFiles are documentation, whose lines are text rather than code
+var proseFiles = []string{"*.md", "*.rst", "*.adoc", "*.txt"}
+
+// CheckMessage returns the URLs and shell commands in a generated commit message that do
+// not appear in the lines added by the diff. Text in the diff can steer the model, so such
+// content may have been injected rather than describe the change; code comments are where
+// injected instructions hide, so they do not count as a source.
+func CheckMessage(message string, diff *git.Diff) []Issue {
+	code := addedCode(diff)
+	grounded := func(s string) bool {
+		for _, line := range code {
+			if strings.Contains(line, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var issues []Issue
+	seen := map[string]bool{}
+	add := func(kind, match, detail string) {
+		if seen[match] || grounded(match) {
+			return
+		}
+		seen[match] = true
+		issues = append(issues, Issue{Kind: kind, Path: messagePath, Detail: fmt.Sprintf(detail, match)})
+	}
+
+	for _, match := range urlPattern.FindAllString(message, -1) {
+		add("url", strings.TrimRight(match, ".,;:"), "URL %s not found in the lines added by the diff")
+	}
+	for _, pattern := range shellPatterns {
+		for _, match := range pattern.FindAllString(message, -1) {
+			add("shell-command", strings.TrimSpace(match), "shell command %q not found in the lines added by the diff")
+		}
+	}
+	return issues
+}
+
+// addedCode returns the added lines of the diff, without their comments outside documentation
+func addedCode(diff *git.Diff) []string {
+	var code []string
+	for i := range diff.Files {
+		f := &diff.Files[i]
+		// Headings and list items in documentation look like comments but are the text itself
+		prose := pathmatch.Match(proseFiles, f.Path)
+		for _, line := range f.AddedLines() {
+			if !prose {
+				line = codePart(line)
+			}
+			if line != "" {
+				code = append(code, line)
+			}
+		}
+	}
+	return code
+}
+
+// codePart returns a line without its comment, or an empty string for a comment line
+func codePart(line string) string {
+	line = strings.TrimSpace(line)
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return ""
+		}
+	}
+	for _, marker := range trailingComments {
+		if i := strings.Index(line, marker); i >= 0 {
+			line = line[:i]
+		}
+	}
+	return line
+}
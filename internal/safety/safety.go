@@ -0,0 +1,142 @@
+package safety
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algernon-coop/git-auto-commit/internal/config"
+	"github.com/algernon-coop/git-auto-commit/internal/git"
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+	"github.com/algernon-coop/git-auto-commit/internal/redact"
+)
+
+// defaultMaxFileSize is the largest staged file accepted without an override
+const defaultMaxFileSize = 5 << 20
+
+// defaultBinaryPaths are binary formats commonly kept in source repositories
+var defaultBinaryPaths = []string{
+	"*.png",
+	"*.jpg",
+	"*.jpeg",
+	"*.gif",
+	"*.ico",
+	"*.webp",
+	"*.pdf",
+	"*.woff",
+	"*.woff2",
+	"*.ttf",
+	"*.otf",
+	"testdata/",
+}
+
+// Issue describes a problem that blocks the commit
+type Issue struct {
+	Kind   string
+	Path   string
+	Detail string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Path, i.Detail, i.Kind)
+}
+
+// Gate checks staged content for secrets, oversized files, conflict markers and unexpected binaries
+type Gate struct {
+	redactor    *redact.Redactor
+	maxFileSize int64
+	binaryPaths []string
+}
+
+// New creates a Gate from the checks configuration. Secrets are detected with the
+// same rules used to redact diffs, including custom patterns and denied paths.
+func New(cfg *config.ChecksConfig, redaction *config.RedactionConfig) (*Gate, error) {
+	redactor, err := redact.New(redaction)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gate{
+		redactor:    redactor,
+		maxFileSize: defaultMaxFileSize,
+		binaryPaths: append([]string{}, defaultBinaryPaths...),
+	}
+
+	if cfg == nil {
+		return g, nil
+	}
+
+	if cfg.MaxFileSize != 0 {
+		g.maxFileSize = cfg.MaxFileSize
+	}
+
+	if err := pathmatch.Validate(cfg.BinaryPaths); err != nil {
+		return nil, fmt.Errorf("invalid binary path: %w", err)
+	}
+	g.binaryPaths = append(g.binaryPaths, cfg.BinaryPaths...)
+
+	return g, nil
+}
+
+// Check returns the issues found in the staged diff, given the staged size of each file
+func (g *Gate) Check(diff *git.Diff, sizes map[string]int64) []Issue {
+	var issues []Issue
+
+	for _, f := range diff.Files {
+		if f.Status == git.StatusDeleted {
+			continue
+		}
+		if size := sizes[f.Path]; g.maxFileSize > 0 && size > g.maxFileSize {
+			issues = append(issues, Issue{
+				Kind:   "large-file",
+				Path:   f.Path,
+				Detail: fmt.Sprintf("%d bytes exceeds the %d byte limit", size, g.maxFileSize),
+			})
+		}
+		if f.Binary && !pathmatch.Match(g.binaryPaths, f.Path) {
+			issues = append(issues, Issue{
+				Kind:   "binary-file",
+				Path:   f.Path,
+				Detail: "binary file outside the allowed binary paths",
+			})
+		}
+	}
+
+	for _, f := range diff.Files {
+		// Deleting a denied file, such as a committed .env, is what the check asks for
+		if f.Status != git.StatusDeleted && g.redactor.Denied(f.Path) {
+			issues = append(issues, Issue{Kind: "secret", Path: f.Path, Detail: "possible denied-path"})
+			continue
+		}
+
+		// Only added lines can introduce a secret; removing one must not block the commit
+		for _, finding := range g.redactor.Scan(f.Path, f.AddedLines()) {
+			// High-entropy strings are too often hashes or test fixtures to block on
+			if finding.Kind == "high-entropy-string" {
+				continue
+			}
+			issues = append(issues, Issue{Kind: "secret", Path: finding.Path, Detail: "possible " + finding.Kind})
+		}
+	}
+
+	issues = append(issues, conflictMarkers(diff)...)
+
+	return issues
+}
+
+// conflictMarkers finds added lines that look like unresolved merge conflict markers
+func conflictMarkers(diff *git.Diff) []Issue {
+	var issues []Issue
+	for _, f := range diff.Files {
+		for _, line := range f.AddedLines() {
+			if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+				issues = append(issues, Issue{
+					Kind:   "conflict-marker",
+					Path:   f.Path,
+					Detail: "unresolved merge conflict marker",
+				})
+				break
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,30 @@
+package whitespace
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/algernon-coop/git-auto-commit/internal/pathmatch"
+)
+
+// significantPatterns are the file types where indentation or other whitespace changes
+// the meaning of the file, so a whitespace-only change is not a formatting change
+var significantPatterns = []string{
+	"*.py", "*.pyi", "*.yml", "*.yaml", "Makefile", "makefile", "GNUmakefile", "*.mk",
+	"*.md", "*.rst", "*.haml", "*.pug", "*.slim", "*.sass", "*.styl", "*.coffee", "*.nim",
+}
+
+// Significant reports whether whitespace is significant in the file at the slash-separated path p
+func Significant(p string) bool {
+	return pathmatch.Match(significantPatterns, p)
+}
+
+// Strip removes every whitespace character from s
+func Strip(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,30 @@
+package whitespace
+
+import "testing"
+
+func TestSignificant(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"web/app.ts", false},
+		{"scripts/build.py", true},
+		{".github/workflows/ci.yml", true},
+		{"deploy/values.yaml", true},
+		{"Makefile", true},
+		{"build/rules.mk", true},
+	}
+
+	for _, tt := range tests {
+		if got := Significant(tt.path); got != tt.want {
+			t.Errorf("Significant(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	if got := Strip(" func main() {\n\trun()\n}"); got != "funcmain(){run()}" {
+		t.Errorf("Strip() = %q", got)
+	}
+}